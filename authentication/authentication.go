@@ -102,6 +102,7 @@ func WithHostPorts(hostsPorts []string) Option {
 type Client struct {
 	cli        *http.Client
 	hostsPorts []string
+	hostsMu    sync.RWMutex
 
 	sessionID      string
 	username       string
@@ -159,6 +160,12 @@ func (client *Client) sessionInner() string {
 }
 
 func (client *Client) Session(refresh bool) (string, error) {
+	return client.SessionAt("", refresh)
+}
+
+// SessionAt is Session, but exchanges the session with the auth service at hostPort, or a random one of the
+// hosts if hostPort is empty.
+func (client *Client) SessionAt(hostPort string, refresh bool) (string, error) {
 	var err error
 	var sessionID string
 	if !refresh {
@@ -168,15 +175,15 @@ func (client *Client) Session(refresh bool) (string, error) {
 		client.mu.Lock()
 		defer client.mu.Unlock()
 
-		err = client.refreshSession()
+		err = client.refreshSession(hostPort)
 		return client.sessionID, err
 	}
 
 	return sessionID, err
 }
 
-func (client *Client) refreshSession() error {
-	resp, err := client.exchange(&exchangeRequest{
+func (client *Client) refreshSession(hostPort string) error {
+	resp, err := client.exchange(hostPort, &exchangeRequest{
 		User:      client.username,
 		PwdSha256: client.passwordSha256,
 	})
@@ -190,13 +197,26 @@ func (client *Client) refreshSession() error {
 	return nil
 }
 
-func (client *Client) patternAddr(pattern string) string {
-	idx := rand.Intn(len(client.hostsPorts))
-	return fmt.Sprintf("https://%v%v", client.hostsPorts[idx], pattern)
+// SetHostPorts replaces the hosts of the auth service, the exchanges in flight keep their hosts.
+func (client *Client) SetHostPorts(hostsPorts []string) {
+	client.hostsMu.Lock()
+	defer client.hostsMu.Unlock()
+
+	client.hostsPorts = hostsPorts
+}
+
+func (client *Client) patternAddr(hostPort, pattern string) string {
+	if hostPort == "" {
+		client.hostsMu.RLock()
+		defer client.hostsMu.RUnlock()
+
+		hostPort = client.hostsPorts[rand.Intn(len(client.hostsPorts))]
+	}
+	return fmt.Sprintf("https://%v%v", hostPort, pattern)
 }
 
-func (client *Client) post(pattern string, buffer *bytes.Buffer) ([]byte, error) {
-	resp, err := client.cli.Post(client.patternAddr(pattern), "application/json", buffer) // ignore_security_alert
+func (client *Client) post(hostPort, pattern string, buffer *bytes.Buffer) ([]byte, error) {
+	resp, err := client.cli.Post(client.patternAddr(hostPort, pattern), "application/json", buffer) // ignore_security_alert
 	if err != nil {
 		return nil, gerrors.New(gerrors.ErrorCode_AUTH_FAILED, fmt.Errorf("post error: %v", err))
 	}
@@ -210,14 +230,14 @@ func (client *Client) post(pattern string, buffer *bytes.Buffer) ([]byte, error)
 	return body, nil
 }
 
-func (client *Client) exchange(req *exchangeRequest) (*exchangeResponse, error) {
+func (client *Client) exchange(hostPort string, req *exchangeRequest) (*exchangeResponse, error) {
 	postBody, err := json.Marshal(req)
 	if err != nil {
 		return nil, gerrors.New(gerrors.ErrorCode_AUTH_FAILED, fmt.Errorf("exchange marshal failed: %v", err))
 	}
 
 	var respBody []byte
-	respBody, err = client.post(ExchangePattern, bytes.NewBuffer(postBody))
+	respBody, err = client.post(hostPort, ExchangePattern, bytes.NewBuffer(postBody))
 	if err != nil {
 		return nil, err
 	}
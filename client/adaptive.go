@@ -0,0 +1,226 @@
+// Copyright 2022 Beijing Volcanoengine Technology Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/volcengine/vegraph-go-sdk/gerrors"
+	"github.com/volcengine/vegraph-go-sdk/gremlin"
+	"github.com/volcengine/vegraph-go-sdk/kitex_gen/bytegraph"
+)
+
+const (
+	DefaultAdaptiveInitialLimit   = 16
+	DefaultAdaptiveMinLimit       = 1
+	DefaultAdaptiveMaxLimit       = 256
+	DefaultAdaptiveIncrease       = 1.0
+	DefaultAdaptiveDecreaseFactor = 0.5
+)
+
+// DefaultBackpressureErrorCodes are the error codes of server asking clients to slow down writes.
+var DefaultBackpressureErrorCodes = []gerrors.ErrorCode{
+	gerrors.ErrorCode_EDGE_OVER_QUOTA,
+	gerrors.ErrorCode_PART_OVER_QUOTA,
+	gerrors.ErrorCode_WRITE_STALL,
+	gerrors.ErrorCode_SERVICE_OVERLOAD,
+}
+
+// AdaptiveLimitOptions configures the AIMD limit of in-flight requests of each table, zero fields are replaced
+// with defaults. The limit is multiplied by DecreaseFactor when a query fails with a backpressure error code,
+// and raised by Increase every limit successful requests. Requests over the limit wait for a slot.
+type AdaptiveLimitOptions struct {
+	InitialLimit   int
+	MinLimit       int
+	MaxLimit       int
+	Increase       float64
+	DecreaseFactor float64
+	// BackpressureErrorCodes defaults to DefaultBackpressureErrorCodes.
+	BackpressureErrorCodes []gerrors.ErrorCode
+	// AllRequests applies the limit to all requests, instead of the requests carrying writes only.
+	AllRequests bool
+	// OnLimitChange is called when the integer limit of table changes. The calls of a table are serialized in the
+	// order of changes, they are made with the limiter of table locked, so it must return quickly and must not
+	// submit requests to the client. The calls of different tables may be concurrent.
+	OnLimitChange func(table string, limit int)
+}
+
+func (o AdaptiveLimitOptions) withDefaults() AdaptiveLimitOptions {
+	if o.MinLimit <= 0 {
+		o.MinLimit = DefaultAdaptiveMinLimit
+	}
+	if o.MaxLimit <= 0 {
+		o.MaxLimit = DefaultAdaptiveMaxLimit
+	}
+	if o.MaxLimit < o.MinLimit {
+		o.MaxLimit = o.MinLimit
+	}
+	if o.InitialLimit <= 0 {
+		o.InitialLimit = DefaultAdaptiveInitialLimit
+	}
+	o.InitialLimit = int(math.Max(float64(o.MinLimit), math.Min(float64(o.MaxLimit), float64(o.InitialLimit))))
+	if o.Increase <= 0 {
+		o.Increase = DefaultAdaptiveIncrease
+	}
+	if o.DecreaseFactor <= 0 || o.DecreaseFactor >= 1 {
+		o.DecreaseFactor = DefaultAdaptiveDecreaseFactor
+	}
+	if len(o.BackpressureErrorCodes) == 0 {
+		o.BackpressureErrorCodes = DefaultBackpressureErrorCodes
+	}
+	return o
+}
+
+// writeSteps are the steps of a query modifying the graph, matched against its fingerprint.
+var writeSteps = []string{"addV(", "addE(", ".property(", ".drop("}
+
+func isWriteRequest(request *bytegraph.GremlinQueryRequest) bool {
+	for _, query := range (&Call{Request: request}).Queries() {
+		fp := gremlin.Fingerprint(query)
+		for _, step := range writeSteps {
+			if strings.Contains(fp, step) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type adaptiveLimiters struct {
+	opts AdaptiveLimitOptions
+
+	mu     sync.Mutex
+	tables map[string]*adaptiveLimiter
+}
+
+func newAdaptiveLimiters(opts AdaptiveLimitOptions) *adaptiveLimiters {
+	return &adaptiveLimiters{opts: opts.withDefaults(), tables: make(map[string]*adaptiveLimiter)}
+}
+
+func (ls *adaptiveLimiters) get(table string) *adaptiveLimiter {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	l, ok := ls.tables[table]
+	if !ok {
+		l = &adaptiveLimiter{opts: &ls.opts, table: table, limit: float64(ls.opts.InitialLimit)}
+		ls.tables[table] = l
+	}
+	return l
+}
+
+// acquire waits for a slot of the table of request if the limit applies to it, release must be called with
+// the errors of request.
+func (ls *adaptiveLimiters) acquire(ctx context.Context, request *bytegraph.GremlinQueryRequest) (release func(errs []error), err error) {
+	if !ls.opts.AllRequests && !isWriteRequest(request) {
+		return func([]error) {}, nil
+	}
+	l := ls.get(request.Table)
+	gen, err := l.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return func(errs []error) {
+		l.release(gen, ls.backpressured(errs))
+	}, nil
+}
+
+func (ls *adaptiveLimiters) backpressured(errs []error) bool {
+	for _, err := range errs {
+		if err != nil && gerrors.Contains(ls.opts.BackpressureErrorCodes, gerrors.Code(err)) {
+			return true
+		}
+	}
+	return false
+}
+
+type adaptiveLimiter struct {
+	opts  *AdaptiveLimitOptions
+	table string
+
+	mu       sync.Mutex
+	limit    float64
+	inFlight int
+	// gen is increased by each decrease, so that the requests sent before a decrease don't decrease again.
+	gen     int
+	waiters []chan struct{}
+}
+
+func (l *adaptiveLimiter) allowed() int {
+	return int(l.limit)
+}
+
+func (l *adaptiveLimiter) acquire(ctx context.Context) (int, error) {
+	l.mu.Lock()
+	if l.inFlight < l.allowed() {
+		l.inFlight++
+		gen := l.gen
+		l.mu.Unlock()
+		return gen, nil
+	}
+	waiter := make(chan struct{})
+	l.waiters = append(l.waiters, waiter)
+	l.mu.Unlock()
+
+	select {
+	case <-waiter:
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		return l.gen, nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		for i, w := range l.waiters {
+			if w == waiter {
+				l.waiters = append(l.waiters[:i], l.waiters[i+1:]...)
+				return 0, gerrors.New(gerrors.ErrorCode_NETWORK_ERROR, ctx.Err())
+			}
+		}
+		// the slot was granted after ctx is done
+		l.inFlight--
+		l.wake()
+		return 0, gerrors.New(gerrors.ErrorCode_NETWORK_ERROR, ctx.Err())
+	}
+}
+
+func (l *adaptiveLimiter) release(gen int, backpressured bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inFlight--
+	before := l.allowed()
+	switch {
+	case backpressured && gen == l.gen:
+		l.limit = math.Max(float64(l.opts.MinLimit), l.limit*l.opts.DecreaseFactor)
+		l.gen++
+	case !backpressured:
+		l.limit = math.Min(float64(l.opts.MaxLimit), l.limit+l.opts.Increase/l.limit)
+	}
+	l.wake()
+	// notify with l.mu held, so that the changes are reported one by one in order
+	if limit := l.allowed(); limit != before && l.opts.OnLimitChange != nil {
+		l.opts.OnLimitChange(l.table, limit)
+	}
+}
+
+// wake grants the free slots to waiters in order, l.mu must be held.
+func (l *adaptiveLimiter) wake() {
+	for len(l.waiters) > 0 && l.inFlight < l.allowed() {
+		l.inFlight++
+		close(l.waiters[0])
+		l.waiters = l.waiters[1:]
+	}
+}
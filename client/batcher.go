@@ -0,0 +1,228 @@
+// Copyright 2022 Beijing Volcanoengine Technology Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/volcengine/vegraph-go-sdk/gerrors"
+	"github.com/volcengine/vegraph-go-sdk/kitex_gen/bytegraph"
+)
+
+const (
+	DefaultBatchWindow  = time.Millisecond
+	DefaultMaxBatchSize = 64
+)
+
+// batchKey groups the queries which can be sent in the same request: besides table and protocol, the context
+// values which change how a request is sent, logged or traced should match, since the request is sent with the
+// values of one of them.
+type batchKey struct {
+	table    string
+	protocol ClientProtocol
+	debug    bool
+	routeKey string
+	hostPort string
+	// trace is the trace context of the parent span, so with a Tracer only the queries of the same span are batched.
+	trace string
+}
+
+type pendingBatch struct {
+	key batchKey
+	// ctx is the context of the first query, whose values are used to send the batch.
+	ctx     context.Context
+	queries []string
+	waiters []chan *Result
+	// deadline is the latest deadline of queries, zero if any query has no deadline.
+	deadline   time.Time
+	noDeadline bool
+	timer      *time.Timer
+
+	// sent and active are set when the batch is sent, the request is canceled when no query waits any more.
+	sent   bool
+	active int
+	cancel context.CancelFunc
+}
+
+// batcher coalesces concurrent single query submissions into batch requests. A batch is sent when it reaches
+// maxSize queries, or window after its first query arrived.
+type batcher struct {
+	c       *Client
+	window  time.Duration
+	maxSize int
+
+	mu      sync.Mutex
+	pending map[batchKey]*pendingBatch
+}
+
+func newBatcher(c *Client, window time.Duration, maxSize int) *batcher {
+	if window <= 0 {
+		window = DefaultBatchWindow
+	}
+	if maxSize <= 0 {
+		maxSize = DefaultMaxBatchSize
+	}
+	return &batcher{
+		c:       c,
+		window:  window,
+		maxSize: maxSize,
+		pending: make(map[batchKey]*pendingBatch),
+	}
+}
+
+func (b *batcher) batchKey(ctx context.Context, table string) batchKey {
+	key := batchKey{table: table, protocol: b.c.reqExpectProtocol(ctx)}
+	key.debug, _ = ctx.Value(DebugKey{}).(bool)
+	key.routeKey, _ = ctx.Value(RouteKey{}).(string)
+	key.hostPort, _ = ctx.Value(hostPortKey{}).(string)
+	if b.c.tracer != nil {
+		carrier := make(map[string]string)
+		b.c.tracer.Inject(ctx, carrier)
+		keys := make([]string, 0, len(carrier))
+		for k := range carrier {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var trace strings.Builder
+		for _, k := range keys {
+			trace.WriteString(k + "=" + carrier[k] + ";")
+		}
+		key.trace = trace.String()
+	}
+	return key
+}
+
+func (b *batcher) submit(ctx context.Context, query, table string) *Result {
+	key := b.batchKey(ctx, table)
+	waiter := make(chan *Result, 1)
+
+	b.mu.Lock()
+	batch := b.pending[key]
+	if batch == nil {
+		batch = &pendingBatch{key: key, ctx: valueContext{ctx}}
+		b.pending[key] = batch
+		batch.timer = time.AfterFunc(b.window, func() { b.flush(batch) })
+	}
+	batch.queries = append(batch.queries, query)
+	batch.waiters = append(batch.waiters, waiter)
+	if deadline, ok := ctx.Deadline(); !ok {
+		batch.noDeadline = true
+	} else if deadline.After(batch.deadline) {
+		batch.deadline = deadline
+	}
+	full := len(batch.queries) >= b.maxSize
+	if full {
+		b.take(batch)
+	}
+	b.mu.Unlock()
+
+	if full {
+		go b.send(batch)
+	}
+	select {
+	case result := <-waiter:
+		return result
+	case <-ctx.Done():
+		b.leave(batch, waiter)
+		return &Result{Err: gerrors.New(gerrors.ErrorCode_NETWORK_ERROR, ctx.Err())}
+	}
+}
+
+// take removes batch from pending to send it, with b.mu held.
+func (b *batcher) take(batch *pendingBatch) {
+	delete(b.pending, batch.key)
+	batch.timer.Stop()
+	batch.sent = true
+	batch.active = len(batch.waiters)
+	ctx := batch.ctx
+	if !batch.noDeadline {
+		ctx, batch.cancel = context.WithDeadline(ctx, batch.deadline)
+	} else {
+		ctx, batch.cancel = context.WithCancel(ctx)
+	}
+	batch.ctx = ctx
+}
+
+// leave drops the query of waiter whose context is done: it is removed from batch if not sent yet,
+// otherwise the request is canceled if no other query waits for it.
+func (b *batcher) leave(batch *pendingBatch, waiter chan *Result) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if batch.sent {
+		batch.active--
+		if batch.active == 0 {
+			batch.cancel()
+		}
+		return
+	}
+	for i, w := range batch.waiters {
+		if w == waiter {
+			batch.waiters = append(batch.waiters[:i], batch.waiters[i+1:]...)
+			batch.queries = append(batch.queries[:i], batch.queries[i+1:]...)
+			break
+		}
+	}
+	if len(batch.waiters) == 0 {
+		delete(b.pending, batch.key)
+		batch.timer.Stop()
+	}
+}
+
+func (b *batcher) flush(batch *pendingBatch) {
+	b.mu.Lock()
+	if b.pending[batch.key] != batch {
+		// already sent because it is full, or dropped by its waiters
+		b.mu.Unlock()
+		return
+	}
+	b.take(batch)
+	b.mu.Unlock()
+	b.send(batch)
+}
+
+func (b *batcher) send(batch *pendingBatch) {
+	defer batch.cancel()
+	request := &bytegraph.GremlinQueryRequest{
+		Table:     batch.key.table,
+		Queries:   batch.queries,
+		UseBinary: true,
+	}
+	elems, extras, errs := b.c.submitBatchRequest(batch.ctx, request)
+	results := newResults(elems, extras, errs, len(batch.queries))
+	for i, waiter := range batch.waiters {
+		waiter <- results[i]
+	}
+}
+
+// valueContext keeps the values of a context, without its deadline and cancellation.
+type valueContext struct {
+	context.Context
+}
+
+func (valueContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (valueContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (valueContext) Err() error {
+	return nil
+}
@@ -0,0 +1,331 @@
+// Copyright 2022 Beijing Volcanoengine Technology Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/endpoint"
+	"github.com/cloudwego/kitex/pkg/kerrors"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+
+	"github.com/volcengine/vegraph-go-sdk/gerrors"
+	"github.com/volcengine/vegraph-go-sdk/kitex_gen/bytegraph"
+)
+
+const (
+	DefaultBreakerWindow           = 10 * time.Second
+	DefaultBreakerMinRequests      = 20
+	DefaultBreakerOpenTimeout      = 5 * time.Second
+	DefaultBreakerHalfOpenRequests = 1
+)
+
+// DefaultBreakerTripRatios opens a breaker when half of the queries are failed by overload or network.
+var DefaultBreakerTripRatios = map[gerrors.ErrorCode]float64{
+	gerrors.ErrorCode_SERVICE_OVERLOAD: 0.5,
+	gerrors.ErrorCode_WRITE_STALL:      0.5,
+	gerrors.ErrorCode_NETWORK_ERROR:    0.5,
+}
+
+type BreakerState int
+
+const (
+	// BreakerClosed lets requests pass, and counts their error codes.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen rejects requests with ErrorCode_CIRCUIT_OPEN, until OpenTimeout passed.
+	BreakerOpen
+	// BreakerHalfOpen lets HalfOpenRequests trial requests pass, it is closed if they all succeed, or opened again.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// BreakerScope is what a circuit breaker is keyed by.
+type BreakerScope int
+
+const (
+	BreakerScopeTable BreakerScope = 1 << iota
+	// BreakerScopeHost breakers reject a host when the load balancer picks it, so another host is tried.
+	BreakerScopeHost
+)
+
+// BreakerOptions configures the circuit breakers, zero fields are replaced with defaults.
+type BreakerOptions struct {
+	// Scopes are the keys of breakers, defaults to BreakerScopeTable | BreakerScopeHost.
+	Scopes BreakerScope
+	// Window is the period the error codes are counted in by a closed breaker.
+	Window time.Duration
+	// MinRequests is the min number of queries in window before a breaker can be opened.
+	MinRequests int
+	// TripRatios opens a breaker when the ratio of queries failed by any of the error codes reaches its value,
+	// defaults to DefaultBreakerTripRatios.
+	TripRatios map[gerrors.ErrorCode]float64
+	// OpenTimeout is the time a breaker keeps open before turning half-open.
+	OpenTimeout time.Duration
+	// HalfOpenRequests is the number of trial requests of a half-open breaker. The trials without result are
+	// let pass again after OpenTimeout.
+	HalfOpenRequests int
+	// OnStateChange is called on each state change of breakers, name is the table or host of the breaker.
+	OnStateChange func(scope BreakerScope, name string, from, to BreakerState)
+}
+
+func (o BreakerOptions) withDefaults() BreakerOptions {
+	if o.Scopes == 0 {
+		o.Scopes = BreakerScopeTable | BreakerScopeHost
+	}
+	if o.Window <= 0 {
+		o.Window = DefaultBreakerWindow
+	}
+	if o.MinRequests <= 0 {
+		o.MinRequests = DefaultBreakerMinRequests
+	}
+	if len(o.TripRatios) == 0 {
+		o.TripRatios = DefaultBreakerTripRatios
+	}
+	if o.OpenTimeout <= 0 {
+		o.OpenTimeout = DefaultBreakerOpenTimeout
+	}
+	if o.HalfOpenRequests <= 0 {
+		o.HalfOpenRequests = DefaultBreakerHalfOpenRequests
+	}
+	return o
+}
+
+type breaker struct {
+	state       BreakerState
+	windowStart time.Time
+	total       int
+	codes       map[gerrors.ErrorCode]int
+	openedAt    time.Time
+	trials      int
+	succeeded   int
+	// trialAt is when the latest trial was let pass, the trials without result expire OpenTimeout after it.
+	trialAt time.Time
+}
+
+type breakerKey struct {
+	scope BreakerScope
+	name  string
+}
+
+type stateChange struct {
+	key      breakerKey
+	from, to BreakerState
+}
+
+type breakers struct {
+	opts BreakerOptions
+
+	mu       sync.Mutex
+	breakers map[breakerKey]*breaker
+}
+
+func newBreakers(opts BreakerOptions) *breakers {
+	return &breakers{opts: opts.withDefaults(), breakers: make(map[breakerKey]*breaker)}
+}
+
+// get returns the breaker of key, bs.mu must be held.
+func (bs *breakers) get(key breakerKey) *breaker {
+	b, ok := bs.breakers[key]
+	if !ok {
+		b = &breaker{codes: make(map[gerrors.ErrorCode]int)}
+		bs.breakers[key] = b
+	}
+	return b
+}
+
+// setState must be called with bs.mu held, the returned change should be notified after unlocking.
+func (bs *breakers) setState(key breakerKey, b *breaker, state BreakerState, now time.Time) *stateChange {
+	change := &stateChange{key: key, from: b.state, to: state}
+	b.state = state
+	b.trials, b.succeeded = 0, 0
+	switch state {
+	case BreakerOpen:
+		b.openedAt = now
+	case BreakerClosed:
+		b.resetWindow(now)
+	}
+	return change
+}
+
+func (bs *breakers) notify(change *stateChange) {
+	if change != nil && bs.opts.OnStateChange != nil {
+		bs.opts.OnStateChange(change.key.scope, change.key.name, change.from, change.to)
+	}
+}
+
+func (b *breaker) resetWindow(now time.Time) {
+	b.windowStart = now
+	b.total = 0
+	b.codes = make(map[gerrors.ErrorCode]int)
+}
+
+// allow reports whether a request can pass the breaker of key.
+func (bs *breakers) allow(scope BreakerScope, name string) bool {
+	if bs.opts.Scopes&scope == 0 {
+		return true
+	}
+	key := breakerKey{scope: scope, name: name}
+	now := time.Now()
+	var change *stateChange
+	defer func() { bs.notify(change) }()
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	b := bs.get(key)
+	if b.state == BreakerOpen && now.Sub(b.openedAt) >= bs.opts.OpenTimeout {
+		change = bs.setState(key, b, BreakerHalfOpen, now)
+	}
+	switch b.state {
+	case BreakerOpen:
+		return false
+	case BreakerHalfOpen:
+		if b.trials >= bs.opts.HalfOpenRequests && now.Sub(b.trialAt) >= bs.opts.OpenTimeout {
+			// the results of some trials are lost, let others pass
+			b.trials = b.succeeded
+		}
+		if b.trials >= bs.opts.HalfOpenRequests {
+			return false
+		}
+		b.trials++
+		b.trialAt = now
+	}
+	return true
+}
+
+// record counts the error codes of the queries of a request passed the breaker of key. Empty errs means the
+// request has no result, eg it is canceled, which gives back the trial of a half-open breaker.
+func (bs *breakers) record(scope BreakerScope, name string, errs []error) {
+	if bs.opts.Scopes&scope == 0 {
+		return
+	}
+	key := breakerKey{scope: scope, name: name}
+	now := time.Now()
+	var change *stateChange
+	defer func() { bs.notify(change) }()
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	b := bs.get(key)
+	if len(errs) == 0 {
+		if b.state == BreakerHalfOpen && b.trials > b.succeeded {
+			b.trials--
+		}
+		return
+	}
+	switch b.state {
+	case BreakerHalfOpen:
+		for _, err := range errs {
+			if _, ok := bs.opts.TripRatios[gerrors.Code(err)]; ok {
+				change = bs.setState(key, b, BreakerOpen, now)
+				return
+			}
+		}
+		if b.succeeded++; b.succeeded >= bs.opts.HalfOpenRequests {
+			change = bs.setState(key, b, BreakerClosed, now)
+		}
+	case BreakerClosed:
+		if now.Sub(b.windowStart) >= bs.opts.Window {
+			b.resetWindow(now)
+		}
+		b.total += len(errs)
+		for _, err := range errs {
+			if code := gerrors.Code(err); code != gerrors.ErrorCode_SUCCESS {
+				b.codes[code]++
+			}
+		}
+		if b.total < bs.opts.MinRequests {
+			return
+		}
+		for code, ratio := range bs.opts.TripRatios {
+			if float64(b.codes[code]) >= ratio*float64(b.total) {
+				change = bs.setState(key, b, BreakerOpen, now)
+				return
+			}
+		}
+	}
+}
+
+func (bs *breakers) intercept(next Endpoint) Endpoint {
+	return func(ctx context.Context, call *Call) {
+		if !bs.allow(BreakerScopeTable, call.Table) {
+			call.Errs = gerrors.DuplicateErr(gerrors.New(gerrors.ErrorCode_CIRCUIT_OPEN, gerrors.ErrCircuitOpen), len(call.Queries()))
+			return
+		}
+		next(ctx, call)
+		bs.record(BreakerScopeTable, call.Table, call.Errs)
+	}
+}
+
+// instanceMiddleware rejects the host picked by load balancer if its breaker is open. The rejection is
+// an ErrCircuitBreak of kitex, so that the load balancer picks another host. The result of each host is
+// recorded here, so every trial of a half-open breaker gets exactly one result.
+func (bs *breakers) instanceMiddleware(next endpoint.Endpoint) endpoint.Endpoint {
+	return func(ctx context.Context, req, resp interface{}) error {
+		ri := rpcinfo.GetRPCInfo(ctx)
+		if ri == nil || ri.To() == nil || ri.To().Address() == nil {
+			return next(ctx, req, resp)
+		}
+		host := ri.To().Address().String()
+		if !bs.allow(BreakerScopeHost, host) {
+			return kerrors.ErrCircuitBreak.WithCause(gerrors.New(gerrors.ErrorCode_CIRCUIT_OPEN, gerrors.ErrCircuitOpen))
+		}
+		err := next(ctx, req, resp)
+		bs.record(BreakerScopeHost, host, instanceErrs(ctx, req, resp, err))
+		return err
+	}
+}
+
+// instanceErrs returns the errors of the queries sent to a host by the kitex call, or nil if the call has no
+// result of the host, eg rejected by another instance middleware or canceled by caller.
+func instanceErrs(ctx context.Context, req, resp interface{}, err error) []error {
+	if ctx.Err() != nil || errors.Is(err, kerrors.ErrCircuitBreak) {
+		return nil
+	}
+	batchSize := 1
+	if args, ok := req.(*bytegraph.ByteGraphServiceGremlinQueryArgs); ok && args.GetReq() != nil {
+		batchSize = requestBatchSize(args.GetReq())
+	}
+	if err != nil {
+		return gerrors.DuplicateErr(gerrors.New(gerrors.ErrorCode_NETWORK_ERROR, err), batchSize)
+	}
+	var response *bytegraph.GremlinQueryResponse
+	if result, ok := resp.(*bytegraph.ByteGraphServiceGremlinQueryResult); ok {
+		response = result.GetSuccess()
+	}
+	if err = validateResponse(response, batchSize); err != nil {
+		return gerrors.DuplicateErr(err, batchSize)
+	}
+	errs := make([]error, len(response.BatchErrCode))
+	for i, code := range response.BatchErrCode {
+		if code != bytegraph.ErrorCode_SUCCESS {
+			errs[i] = gerrors.New(gerrors.ErrorCode(code), errors.New(batchDesc(response, i)))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,132 @@
+// Copyright 2022 Beijing Volcanoengine Technology Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"sync"
+
+	"github.com/volcengine/vegraph-go-sdk/gerrors"
+	"github.com/volcengine/vegraph-go-sdk/kitex_gen/bytegraph"
+	"github.com/volcengine/vegraph-go-sdk/structure"
+)
+
+const (
+	DefaultChunkSize        = 500
+	DefaultChunkBytes       = 4 << 20
+	DefaultChunkParallelism = 4
+)
+
+// ChunkOptions controls how BatchSubmitChunked splits and sends queries, zero fields are replaced with defaults.
+type ChunkOptions struct {
+	// MaxChunkSize is the max number of queries in a chunk.
+	MaxChunkSize int
+	// MaxChunkBytes is the max total length of queries in a chunk, a query longer than it is sent alone.
+	MaxChunkBytes int
+	// Parallelism is the max number of chunks in flight.
+	Parallelism int
+	// Progress is called after each chunk finished, with the number of finished and total queries.
+	// Calls are serialized, so it needn't be goroutine-safe.
+	Progress func(done, total int)
+}
+
+func (o ChunkOptions) withDefaults() ChunkOptions {
+	if o.MaxChunkSize <= 0 {
+		o.MaxChunkSize = DefaultChunkSize
+	}
+	if o.MaxChunkBytes <= 0 {
+		o.MaxChunkBytes = DefaultChunkBytes
+	}
+	if o.Parallelism <= 0 {
+		o.Parallelism = DefaultChunkParallelism
+	}
+	return o
+}
+
+type chunk struct {
+	start, end int
+}
+
+// BatchSubmitChunked works like BatchSubmit, but splits queries into chunks by count and bytes,
+// and sends them concurrently. Results and errors keep the order of queries.
+func (c *Client) BatchSubmitChunked(ctx context.Context, queries []string, opts ChunkOptions, table ...string) ([]structure.Element, []error) {
+	reqTable, err := c.reqTable(table...)
+	if err != nil {
+		return nil, []error{err}
+	}
+	opts = opts.withDefaults()
+	elems := make([]structure.Element, len(queries))
+	errs := make([]error, len(queries))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	done := 0
+	sem := make(chan struct{}, opts.Parallelism)
+	for _, ck := range splitChunks(queries, opts.MaxChunkSize, opts.MaxChunkBytes) {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			cancelErr := gerrors.New(gerrors.ErrorCode_NETWORK_ERROR, ctx.Err())
+			for i := ck.start; i < len(queries); i++ {
+				errs[i] = cancelErr
+			}
+			wg.Wait()
+			return elems, errs
+		}
+		wg.Add(1)
+		go func(ck chunk) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			request := &bytegraph.GremlinQueryRequest{
+				Table:     reqTable,
+				Queries:   queries[ck.start:ck.end],
+				UseBinary: true,
+			}
+			chunkElems, chunkExtras, chunkErrs := c.submitBatchRequest(ctx, request)
+			results := newResults(chunkElems, chunkExtras, chunkErrs, ck.end-ck.start)
+			for i, result := range results {
+				elems[ck.start+i] = result.Element
+				errs[ck.start+i] = result.Err
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			done += ck.end - ck.start
+			if opts.Progress != nil {
+				opts.Progress(done, len(queries))
+			}
+		}(ck)
+	}
+	wg.Wait()
+	return elems, errs
+}
+
+func splitChunks(queries []string, maxSize, maxBytes int) []chunk {
+	var chunks []chunk
+	start, bytes := 0, 0
+	for i, query := range queries {
+		if i > start && (i-start >= maxSize || bytes+len(query) > maxBytes) {
+			chunks = append(chunks, chunk{start: start, end: i})
+			start, bytes = i, 0
+		}
+		bytes += len(query)
+	}
+	if start < len(queries) {
+		chunks = append(chunks, chunk{start: start, end: len(queries)})
+	}
+	return chunks
+}
@@ -19,8 +19,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net"
-	"strconv"
+	"os"
 	"sync"
 	"time"
 
@@ -58,31 +57,43 @@ type Client struct {
 
 	// authentication
 	authType AuthType
+	authPort int
 	auth     authentication.IClient
 	mux      sync.RWMutex
 
 	decodeUseStruct bool
 	compression     bool
+	expectProtocol  ClientProtocol
+	retryPolicy     *RetryPolicy
+	tracer          Tracer
+	logger          Logger
+	batcher         *batcher
+	endpoint        Endpoint
+	hostPorts       []string
+	closed          chan struct{}
+	closeOnce       sync.Once
+
+	// adaptiveLimiters limit the in-flight requests of each table, see WithAdaptiveLimit
+	adaptiveLimiters *adaptiveLimiters
+	// health ejects the unhealthy hosts, see WithHealthCheck
+	health *healthChecker
 }
 
+// DebugKey is the context key to log the debug events of a single request by the default Logger,
+// eg: context.WithValue(ctx, DebugKey{}, true)
 type DebugKey struct {
 }
 
+// ExpectProtocolKey is the context key to specify the ClientProtocol of a single request,
+// eg: context.WithValue(ctx, ExpectProtocolKey{}, ClientProtocol_ColumnarV1)
+type ExpectProtocolKey struct {
+}
+
+// NewDebugMiddleWare logs requests and responses to stdout when DebugKey{} is in the context.
+//
+// Deprecated: clients log rpcs at LevelDebug by themselves, see WithLogger.
 func NewDebugMiddleWare() endpoint.Middleware {
-	return func(next endpoint.Endpoint) endpoint.Endpoint {
-		return func(ctx context.Context, req, resp interface{}) (err error) {
-			err = next(ctx, req, resp)
-			val := ctx.Value(DebugKey{})
-			if debug, ok := val.(bool); ok && debug {
-				fmt.Println("-----------------")
-				fmt.Printf("request: %+v\n", req)
-				fmt.Printf("resp: %+v\n", resp)
-				fmt.Printf("err: %+v\n", err)
-				fmt.Println("-----------------")
-			}
-			return
-		}
-	}
+	return newLogMiddleware(NewTextLogger(os.Stdout, LevelInfo), DefaultLogPayloadLimit)
 }
 
 // Create a goroutine-safe ByteGraph client.
@@ -94,44 +105,92 @@ func NewClient(ops ...Option) (*Client, error) {
 	client := &Client{
 		table:           opts.DefaultTable,
 		authType:        opts.authType,
+		authPort:        opts.authPort,
 		decodeUseStruct: opts.DecodeUseStruct,
 		compression:     opts.compression,
+		expectProtocol:  opts.expectProtocol,
+		retryPolicy:     opts.retryPolicy,
+		tracer:          opts.tracer,
+		logger:          opts.logger,
 		mux:             sync.RWMutex{},
+		closed:          make(chan struct{}),
+	}
+	if opts.adaptiveLimit != nil {
+		client.adaptiveLimiters = newAdaptiveLimiters(*opts.adaptiveLimit)
+	}
+	// hosts are the hosts to pin requests to, without the ejected ones
+	hosts := client.getHostPorts
+	if opts.healthCheck != nil {
+		client.health = newHealthChecker(*opts.healthCheck, client)
+		hosts = client.health.healthyHosts
+	}
+	var interceptors []Interceptor
+	if opts.metrics != nil {
+		interceptors = append(interceptors, newClientMetrics(opts.metrics).intercept)
+	}
+	if opts.slowQuery != nil {
+		interceptors = append(interceptors, newSlowQueryLog(*opts.slowQuery, client).intercept)
+	}
+	interceptors = append(interceptors, opts.interceptors...)
+	var breakers *breakers
+	if opts.breaker != nil {
+		breakers = newBreakers(*opts.breaker)
+		interceptors = append(interceptors, breakers.intercept)
+	}
+	if opts.limit != nil || len(opts.tableLimits) > 0 {
+		interceptors = append(interceptors, newLimiters(opts.limit, opts.tableLimits).intercept)
+	}
+	if opts.hashRouting {
+		interceptors = append(interceptors, newRouter(opts.virtualNodes, hosts).intercept)
+	}
+	if opts.hedge != nil {
+		interceptors = append(interceptors, newHedger(*opts.hedge, hosts).intercept)
+	}
+	if client.health != nil {
+		interceptors = append(interceptors, client.health.intercept)
+	}
+	client.endpoint = chainInterceptors(interceptors, client.invoke)
+	if opts.batching {
+		client.batcher = newBatcher(client, opts.batchWindow, opts.maxBatchSize)
 	}
 
 	var authHostPorts []string
-	if len(opts.domainName) != 0 {
-		es, err := net.LookupHost(opts.domainName)
+	var resolver *hostResolver
+	if opts.resolver == nil && len(opts.domainName) != 0 {
+		opts.resolver = NewDNSResolver(opts.domainName, opts.port)
+	}
+	if opts.resolver != nil {
+		resolver = newHostResolver(opts.resolver, opts)
+		hostPorts, resolvedAuthHostPorts, err := resolver.lookup(context.Background())
 		if err != nil {
-			err = fmt.Errorf("LookupHost(%v): %s", opts.domainName, err.Error())
 			return nil, err
 		}
-		for _, host := range es {
-			ip := net.ParseIP(host).String()
-			opts.HostPorts = append(opts.HostPorts, net.JoinHostPort(ip, strconv.Itoa(opts.port)))
-			if client.authType == AuthType_PasswordSha256 {
-				authHostPorts = append(authHostPorts, fmt.Sprintf("%v:%v", host, opts.authPort))
-			}
-		}
-
+		opts.HostPorts = hostPorts
+		authHostPorts = resolvedAuthHostPorts
 	} else if client.authType == AuthType_PasswordSha256 && len(opts.HostPorts) > 0 {
-		for _, hostPort := range opts.HostPorts {
-			host, _, err := net.SplitHostPort(hostPort)
-			if err != nil {
-				err = fmt.Errorf("SplitHostPort(%v): %s", hostPort, err.Error())
-				return nil, err
-			}
-			authHostPorts = append(authHostPorts, fmt.Sprintf("%v:%v", host, opts.authPort))
+		var err error
+		if authHostPorts, err = deriveAuthHostPorts(opts.HostPorts, opts.authPort); err != nil {
+			return nil, err
 		}
 	}
+	client.hostPorts = opts.HostPorts
 	client.auth = authentication.NewClient(authentication.WithUserPwdSha256(opts.userName, opts.passwordSha256),
 		authentication.WithHostPorts(authHostPorts))
 
 	kitexOpts := make([]kitex.Option, 0)
-	if len(opts.HostPorts) > 0 {
+	if resolver != nil && resolver.interval > 0 {
+		kitexOpts = append(kitexOpts, kitex.WithResolver(resolver.kitexResolver(client)))
+	} else if len(opts.HostPorts) > 0 {
 		kitexOpts = append(kitexOpts, kitex.WithHostPorts(opts.HostPorts...))
 	}
-	kitexOpts = append(kitexOpts, kitex.WithMiddleware(NewDebugMiddleWare()))
+	kitexOpts = append(kitexOpts, kitex.WithMiddleware(newLogMiddleware(client.logger, opts.logPayloadLimit)),
+		kitex.WithMiddleware(peerMiddleware))
+	if breakers != nil {
+		kitexOpts = append(kitexOpts, kitex.WithInstanceMW(breakers.instanceMiddleware))
+	}
+	if client.health != nil {
+		kitexOpts = append(kitexOpts, kitex.WithInstanceMW(client.health.instanceMiddleware))
+	}
 	kitexOpts = append(kitexOpts,
 		kitex.WithLongConnection(connpool.IdleConfig{
 			MaxIdleGlobal:     opts.MaxIdleGlobal,
@@ -147,6 +206,12 @@ func NewClient(ops ...Option) (*Client, error) {
 		return nil, gerrors.New(gerrors.ErrorCode_NETWORK_ERROR, err)
 	}
 	client.klient = clt
+	if resolver != nil && resolver.interval > 0 {
+		go resolver.watch(client)
+	}
+	if client.health != nil {
+		go client.health.watch()
+	}
 	return client, nil
 }
 
@@ -156,6 +221,12 @@ func (c *Client) getKlient() bytegraphservice.Client {
 	return c.klient
 }
 
+func (c *Client) getHostPorts() []string {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+	return c.hostPorts
+}
+
 func (c *Client) setklient(klient bytegraphservice.Client) {
 	c.mux.Lock()
 	defer c.mux.Unlock()
@@ -188,10 +259,37 @@ func authBase(reqBase *base.Base, newUserExtra map[string]string) *base.Base {
 	return reqBase
 }
 
+// authHostPortKey is the context key to exchange the session with the auth service at the host port.
+type authHostPortKey struct{}
+
+// session returns the session of c.auth, exchanged with the auth host in ctx if the auth client supports it.
+func (c *Client) session(ctx context.Context, refresh bool) (string, error) {
+	if hostPort, ok := ctx.Value(authHostPortKey{}).(string); ok {
+		if auth, ok := c.auth.(interface {
+			SessionAt(hostPort string, refresh bool) (string, error)
+		}); ok {
+			return auth.SessionAt(hostPort, refresh)
+		}
+	}
+	return c.auth.Session(refresh)
+}
+
+// submit sends request to server with the authentication of c.
+func (c *Client) submit(ctx context.Context, request *bytegraph.GremlinQueryRequest) (*bytegraph.GremlinQueryResponse, error) {
+	switch c.authType {
+	case AuthType_PasswordSha256:
+		return c.submitBatchRequestAuthSha256(ctx, request)
+	case AuthType_PasswordEncrypt:
+		return c.submitBatchRequestAuthEncrypted(ctx, request)
+	default:
+		return c.gremlinQuery(ctx, request)
+	}
+}
+
 func (c *Client) submitBatchRequestAuthSha256(ctx context.Context, request *bytegraph.GremlinQueryRequest) (resp *bytegraph.GremlinQueryResponse, err error) {
 	var sessID string
 	for retryCnt := 2; retryCnt > 0; retryCnt-- {
-		sessID, err = c.auth.Session(false)
+		sessID, err = c.session(ctx, false)
 		if err != nil {
 			return nil, err
 		}
@@ -201,7 +299,7 @@ func (c *Client) submitBatchRequestAuthSha256(ctx context.Context, request *byte
 			authentication.PersistUserKey:    c.auth.UserName(),
 		})
 
-		resp, err = c.getKlient().GremlinQuery(ctx, request)
+		resp, err = c.gremlinQuery(ctx, request)
 		if err == nil {
 			return resp, nil
 		}
@@ -209,9 +307,17 @@ func (c *Client) submitBatchRequestAuthSha256(ctx context.Context, request *byte
 			return nil, gerrors.New(gerrors.ErrorCode_NETWORK_ERROR, err)
 		}
 
-		if _, err = c.auth.Session(true); err == nil || authentication.NotExistErr(err.Error()) {
+		if _, err = c.session(ctx, true); err != nil {
+			spanFromContext(ctx).AddEvent(EventAuthRefresh, Attribute{Key: AttrError, Value: err.Error()})
+			c.logEvent(ctx, LevelWarn, "refresh session failed", func() []Attribute {
+				return []Attribute{{Key: "user", Value: c.auth.UserName()}, {Key: "err", Value: err.Error()}}
+			})
 			continue
 		}
+		spanFromContext(ctx).AddEvent(EventAuthRefresh)
+		c.logEvent(ctx, LevelInfo, "session refreshed", func() []Attribute {
+			return []Attribute{{Key: "user", Value: c.auth.UserName()}}
+		})
 	}
 
 	return nil, gerrors.New(gerrors.ErrorCode_NETWORK_ERROR, err)
@@ -222,7 +328,7 @@ func (c *Client) submitBatchRequestAuthEncrypted(ctx context.Context, request *b
 		authentication.PersistPwdKey:  c.auth.Password(),
 		authentication.PersistUserKey: c.auth.UserName(),
 	})
-	return c.getKlient().GremlinQuery(ctx, request)
+	return c.gremlinQuery(ctx, request)
 }
 
 // need to check first err with ErrorCode_SYSTEM_ERROR and ErrorCode_INVALID_REQUEST
@@ -237,18 +343,16 @@ func (c *Client) BatchSubmit(ctx context.Context, query []string, table ...strin
 	}
 	request.Table = reqTable
 	elems, _, errs := c.submitBatchRequest(ctx, request)
-	var respErrs []error
-	if len(errs) > 0 {
-		respErrs = errs
-	} else {
-		respErrs = []error{gerrors.New(gerrors.ErrorCode_SYSTEM_ERROR, fmt.Errorf("unexpected error number returned by submitTemplates: %v", errs))}
-	}
 	// respErrs may be not equal to length of query due to ErrorCode_SYSTEM_ERROR and ErrorCode_INVALID_REQUEST
-	return elems, respErrs
+	return elems, batchErrors(errs)
 }
 
 // table is used to specify a temporary table in replace of default table to use in the request.
 func (c *Client) Submit(ctx context.Context, query string, table ...string) (structure.Element, error) {
+	if c.batcher != nil {
+		result := c.SubmitEx(ctx, query, table...)
+		return result.Element, result.Err
+	}
 	request := &bytegraph.GremlinQueryRequest{
 		Queries:   []string{query},
 		UseBinary: true,
@@ -259,7 +363,19 @@ func (c *Client) Submit(ctx context.Context, query string, table ...string) (str
 	}
 	request.Table = reqTable
 	elems, _, errs := c.submitBatchRequest(ctx, request)
+	return firstResult(elems, errs)
+}
+
+func batchErrors(errs []error) []error {
+	if len(errs) > 0 {
+		return errs
+	}
+	return []error{gerrors.New(gerrors.ErrorCode_SYSTEM_ERROR, fmt.Errorf("unexpected error number returned by submitTemplates: %v", errs))}
+}
+
+func firstResult(elems []structure.Element, errs []error) (structure.Element, error) {
 	var elem structure.Element
+	var err error
 	if len(errs) > 0 {
 		err = errs[0]
 	} else {
@@ -271,56 +387,140 @@ func (c *Client) Submit(ctx context.Context, query string, table ...string) (str
 	return elem, err
 }
 
+// requestBatchSize returns the number of queries carried by request, templates take precedence over queries.
+func requestBatchSize(request *bytegraph.GremlinQueryRequest) int {
+	if len(request.Templates) > 0 {
+		return len(request.Templates)
+	}
+	return len(request.Queries)
+}
+
 // 1. size of []error keeps equal to the number of queries in request;
 // 2. the order of []error is keep the same as the order of queries in request;
 // 3. ErrorCode_SUCCESS is promised to be converted to nil when returned by []error
-func (c *Client) submitBatchRequest(ctx context.Context, request *bytegraph.GremlinQueryRequest) ([]structure.Element, []*structure.Extra, []error) {
-	if len(request.Queries) == 0 {
+func (c *Client) submitBatchRequest(ctx context.Context, request *bytegraph.GremlinQueryRequest) (elems []structure.Element, extras []*structure.Extra, errs []error) {
+	if c.tracer != nil {
+		var span Span
+		ctx, span = c.startSpan(ctx, request)
+		defer func() {
+			endSpan(span, extras, errs)
+		}()
+	}
+	if c.adaptiveLimiters != nil && requestBatchSize(request) > 0 {
+		release, err := c.adaptiveLimiters.acquire(ctx, request)
+		if err != nil {
+			return nil, nil, gerrors.DuplicateErr(err, requestBatchSize(request))
+		}
+		defer func() {
+			release(errs)
+		}()
+	}
+	if c.retryPolicy != nil {
+		return c.submitWithRetry(ctx, request, c.retryPolicy)
+	}
+	return c.doSubmitBatchRequest(ctx, request)
+}
+
+// doSubmitBatchRequest sends request once through interceptors, see submitBatchRequest for the returned values.
+func (c *Client) doSubmitBatchRequest(ctx context.Context, request *bytegraph.GremlinQueryRequest) ([]structure.Element, []*structure.Extra, []error) {
+	var batchSize = requestBatchSize(request)
+	if batchSize == 0 {
 		return []structure.Element{}, []*structure.Extra{}, []error{}
 	}
-	var batchSize = len(request.Queries)
 	if c.compression {
 		request.Compression = c.compression
 	}
-	var err error
-	var resp *bytegraph.GremlinQueryResponse
-	if c.authType == AuthType_PasswordSha256 {
-		resp, err = c.submitBatchRequestAuthSha256(ctx, request)
-	} else if c.authType == AuthType_PasswordEncrypt {
-		resp, err = c.submitBatchRequestAuthEncrypted(ctx, request)
-	} else {
-		resp, err = c.getKlient().GremlinQuery(ctx, request)
+	request.ExpectProtocol = c.reqExpectProtocol(ctx)
+	call := &Call{
+		Table:    request.Table,
+		Request:  request,
+		AuthType: c.authType,
+	}
+	c.endpoint(ctx, call)
+	if len(call.Errs) != batchSize {
+		err := gerrors.New(gerrors.ErrorCode_SYSTEM_ERROR, fmt.Errorf("unexpected error number %d of call with %d queries", len(call.Errs), batchSize))
+		return nil, nil, gerrors.DuplicateErr(err, batchSize)
 	}
+	return call.Results, call.Extras, call.Errs
+}
 
+// invoke is the innermost Endpoint, which sends the request of call to server and decodes the response.
+func (c *Client) invoke(ctx context.Context, call *Call) {
+	request := call.Request
+	batchSize := requestBatchSize(request)
+	ctx, peer := withPeer(ctx)
+	start := time.Now()
+	resp, err := c.submit(ctx, request)
+	call.RPCLatency = time.Since(start)
+	call.Host = peer.addr
+	call.Response = resp
+
+	if err == nil {
+		err = validateResponse(resp, batchSize)
+	} else if gerrors.Code(err) == gerrors.ErrorCode_CIRCUIT_OPEN {
+		// all hosts are rejected by their breakers
+		err = gerrors.New(gerrors.ErrorCode_CIRCUIT_OPEN, err)
+	} else {
+		err = gerrors.New(gerrors.ErrorCode_NETWORK_ERROR, err)
+	}
 	if err != nil {
-		return nil, nil, gerrors.DuplicateErr(gerrors.New(gerrors.ErrorCode_NETWORK_ERROR, err), batchSize)
+		c.logEvent(ctx, LevelWarn, "gremlin request failed", func() []Attribute {
+			return []Attribute{{Key: "table", Value: call.Table}, {Key: "host", Value: call.Host},
+				{Key: "batch_size", Value: int64(batchSize)}, {Key: "err", Value: err.Error()}}
+		})
+		call.Errs = gerrors.DuplicateErr(err, batchSize)
+		return
 	}
 
+	start = time.Now()
+	defer func() {
+		call.DecodeLatency = time.Since(start)
+	}()
 	results := make([]structure.Element, batchSize)
 	extras := make([]*structure.Extra, batchSize)
 	errs := make([]error, batchSize)
 	rets := resp.BatchBinaryRet
-	if batchSize != len(resp.BatchErrCode) {
-		panic(fmt.Sprintf("unexpected batch size of req and resp not equal:%+v %+v %+v %+v", batchSize, resp.BatchErrCode, request, resp))
+	var respExtra map[string]string
+	if resp.BaseResp != nil {
+		respExtra = resp.BaseResp.Extra
 	}
 	for i, berr := range resp.BatchErrCode {
+		extras[i] = &structure.Extra{RespExtra: respExtra}
+		if i < len(resp.Costs) {
+			extras[i].Cost = resp.Costs[i]
+		}
+		if i < len(resp.TxnIds) {
+			extras[i].TxnId = resp.TxnIds[i]
+		}
+		if i < len(resp.TxnTss) {
+			extras[i].TxnTs = resp.TxnTss[i]
+		}
 		if berr != bytegraph.ErrorCode_SUCCESS {
-			errs[i] = gerrors.New(gerrors.ErrorCode(berr), errors.New(resp.BatchDesc[i]))
+			errs[i] = gerrors.New(gerrors.ErrorCode(berr), errors.New(batchDesc(resp, i)))
+			continue
+		}
+		if i >= len(rets) {
+			errs[i] = newProtocolError(resp, "missing result of query %d in %d results", i, len(rets))
 			continue
 		}
-		retBinary := rets[i]
 		var res structure.Element
-		res, err = DecodeEx(retBinary, c.decodeUseStruct)
+		res, err = DecodeEx(rets[i], c.decodeUseStruct)
 		if err != nil {
 			errs[i] = err
 			continue
 		}
 		results[i] = res
-		if i < len(resp.Costs) {
-			extras[i] = &structure.Extra{Cost: resp.Costs[i]}
-		}
 	}
-	return results, extras, errs
+	call.Results, call.Extras, call.Errs = results, extras, errs
+}
+
+// reqExpectProtocol returns the protocol specified in ctx, or the default protocol of client.
+// No fallback handling is needed if server ignores it, since DecodeEx recognizes both binary and columnar results.
+func (c *Client) reqExpectProtocol(ctx context.Context) ClientProtocol {
+	if protocol, ok := ctx.Value(ExpectProtocolKey{}).(ClientProtocol); ok {
+		return protocol
+	}
+	return c.expectProtocol
 }
 
 func (c *Client) reqTable(tables ...string) (string, error) {
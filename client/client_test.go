@@ -2,12 +2,31 @@ package client
 
 import (
 	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/cloudwego/kitex/client/callopt"
+	"github.com/cloudwego/kitex/pkg/kerrors"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/cloudwego/kitex/pkg/utils"
 	json "github.com/json-iterator/go"
 	"github.com/stretchr/testify/assert"
+	"github.com/volcengine/vegraph-go-sdk/authentication"
+	"github.com/volcengine/vegraph-go-sdk/client/metrics"
+	"github.com/volcengine/vegraph-go-sdk/gerrors"
+	"github.com/volcengine/vegraph-go-sdk/gremlin"
 	"github.com/volcengine/vegraph-go-sdk/kitex_gen/bytegraph"
+	"github.com/volcengine/vegraph-go-sdk/provider/columnar"
+	"github.com/volcengine/vegraph-go-sdk/provider/protocol"
 	"github.com/volcengine/vegraph-go-sdk/structure"
 )
 
@@ -32,10 +51,1571 @@ func TestSubmit(t *testing.T) {
 	assert.True(t, edge.Type == "like")
 }
 
+func TestSubmitEx(t *testing.T) {
+	ctx := context.Background()
+	cli, err := NewClient(WithHostPort("ip:port"))
+	assert.True(t, err == nil)
+	cli.setklient(&TMockedClient{})
+
+	result := cli.SubmitEx(ctx, "g.V().has('id',1).has('type',1002).outE('like')", "test")
+	assert.True(t, result.Err == nil)
+	assert.Equal(t, "85b5862c-1ed7-11ed-8822-acde48001122", result.TxnId)
+	assert.Equal(t, int64(1660814652842142), result.TxnTs)
+	assert.Equal(t, "boe", result.RespExtra["idc"])
+	list, ok := result.Element.(structure.List)
+	assert.True(t, ok)
+	assert.True(t, len(list) == 1)
+
+	results := cli.BatchSubmitEx(ctx, []string{"g.V()"})
+	assert.Equal(t, 1, len(results))
+	assert.NotNil(t, results[0].Err)
+}
+
+func TestSubmitTemplate(t *testing.T) {
+	ctx := context.Background()
+	cli, err := NewClient(WithHostPort("ip:port"))
+	assert.True(t, err == nil)
+	mocked := &TMockedClient{}
+	cli.setklient(mocked)
+
+	elements, err := cli.SubmitTemplate(ctx, "g.V().has('id', vid).has('type', vtype).outE('like')",
+		map[string]interface{}{"vid": int64(1), "vtype": int32(1002), "name": "it's", "raw": []byte{0x1}}, "test")
+	assert.True(t, err == nil)
+	_, ok := elements.(structure.List)
+	assert.True(t, ok)
+
+	req := mocked.lastReq
+	assert.Equal(t, 0, len(req.Queries))
+	assert.Equal(t, 1, len(req.Templates))
+	assert.Equal(t, int64(1), *req.Parameters[0]["vid"].Int64Value)
+	assert.Equal(t, int32(1002), *req.Parameters[0]["vtype"].IntValue)
+	assert.Equal(t, "it's", string(req.Parameters[0]["name"].StringValue))
+	assert.Equal(t, []byte{0x1}, req.BinaryParameters[0]["raw"])
+
+	_, err = cli.SubmitTemplate(ctx, "g.V(vid)", map[string]interface{}{"vid": struct{}{}}, "test")
+	assert.NotNil(t, err)
+
+	// the templates of builder are sent as is, with a parameter for each placeholder
+	template, params, err := gremlin.G().V(1, 1001).OutE("like").Has("weight", gremlin.Gt(1.5)).Limit(10).Template()
+	assert.NoError(t, err)
+	_, err = cli.SubmitTemplate(ctx, template, params, "test")
+	assert.NoError(t, err)
+	req = mocked.lastReq
+	assert.Equal(t, []string{"g.V().has('id', p0).has('type', p1).outE('like').has('weight', gt(p2)).limit(p3)"}, req.Templates)
+	assert.Equal(t, int64(1), *req.Parameters[0]["p0"].Int64Value)
+	assert.Equal(t, int64(1001), *req.Parameters[0]["p1"].Int64Value)
+	assert.Equal(t, 1.5, *req.Parameters[0]["p2"].DoubleValue)
+	assert.Equal(t, int64(10), *req.Parameters[0]["p3"].Int64Value)
+	assert.Len(t, req.Parameters[0], 4)
+}
+
+func TestExpectProtocol(t *testing.T) {
+	ctx := context.Background()
+	cli, err := NewClient(WithHostPort("ip:port"), WithExpectProtocol(ClientProtocol_ColumnarV1))
+	assert.True(t, err == nil)
+	mocked := &TMockedClient{}
+	cli.setklient(mocked)
+
+	// the mocked server ignores the expected protocol and returns binary result
+	elements, err := cli.Submit(ctx, "g.V().has('id',1).has('type',1002).outE('like')", "test")
+	assert.True(t, err == nil)
+	assert.Equal(t, ClientProtocol_ColumnarV1, mocked.lastReq.ExpectProtocol)
+	_, ok := elements.(structure.List)
+	assert.True(t, ok)
+
+	ctx = context.WithValue(ctx, ExpectProtocolKey{}, ClientProtocol_Binary)
+	_, err = cli.Submit(ctx, "g.V().has('id',1).has('type',1002).outE('like')", "test")
+	assert.True(t, err == nil)
+	assert.Equal(t, ClientProtocol_Binary, mocked.lastReq.ExpectProtocol)
+}
+
+func TestColumnarResult(t *testing.T) {
+	ctx := context.Background()
+	cli, err := NewClient(WithHostPort("ip:port"), WithDefaultTable("test"), WithExpectProtocol(ClientProtocol_ColumnarV1))
+	assert.NoError(t, err)
+	vertices := structure.List{
+		&structure.Vertex{Id: 1, Type: 1001, Properties: []*structure.Property{{Key: "name", Value: "a"}}},
+		&structure.Vertex{Id: 2, Type: 1001},
+	}
+	binaryServer := false
+	cli.setklient(&TFuncClient{fn: func(req *bytegraph.GremlinQueryRequest) (*bytegraph.GremlinQueryResponse, error) {
+		resp := &bytegraph.GremlinQueryResponse{}
+		if binaryServer || req.ExpectProtocol != ClientProtocol_ColumnarV1 {
+			mockAppendResult(resp, bytegraph.ErrorCode_SUCCESS, vertices)
+			return resp, nil
+		}
+		mockAppendColumnarResult(resp, structure.VertexType, 2, []mockColumn{
+			{name: structure.ClientProtoVtxIdKey, tp: columnar.ValueType_INT64, values: []interface{}{int64(1), int64(2)}},
+			{name: structure.ClientProtoVtxTypeKey, tp: columnar.ValueType_INT32, values: []interface{}{int32(1001), int32(1001)}},
+			{name: "name", tp: columnar.ValueType_STRING, values: []interface{}{"a", nil}},
+		})
+		return resp, nil
+	}})
+
+	// the columnar result is decoded into the same elements as the binary one
+	elements, err := cli.Submit(ctx, "g.V().has('id',1).has('type',1001)")
+	assert.NoError(t, err)
+	assert.True(t, vertices.Eq(elements, true), "%v", elements)
+
+	// the binary result of a server ignoring the expected protocol is decoded as well
+	binaryServer = true
+	elements, err = cli.Submit(ctx, "g.V().has('id',1).has('type',1001)")
+	assert.NoError(t, err)
+	assert.True(t, vertices.Eq(elements, true), "%v", elements)
+}
+
+func TestRetryPolicy(t *testing.T) {
+	ctx := context.Background()
+	cli, err := NewClient(WithHostPort("ip:port"), WithRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}))
+	assert.True(t, err == nil)
+	var sent [][]string
+	cli.setklient(&TFuncClient{fn: func(req *bytegraph.GremlinQueryRequest) (*bytegraph.GremlinQueryResponse, error) {
+		sent = append(sent, req.Queries)
+		resp := &bytegraph.GremlinQueryResponse{}
+		for _, query := range req.Queries {
+			switch {
+			case query == "retry" && len(sent) < 3:
+				mockAppendResult(resp, bytegraph.ErrorCode_RETRY, nil)
+			case query == "invalid":
+				mockAppendResult(resp, bytegraph.ErrorCode_GREMLIN_INVALID_QUERY, nil)
+			default:
+				mockAppendResult(resp, bytegraph.ErrorCode_SUCCESS, structure.String(query))
+			}
+		}
+		return resp, nil
+	}})
+
+	elems, errs := cli.BatchSubmit(ctx, []string{"ok", "retry", "invalid"}, "test")
+	assert.Equal(t, [][]string{{"ok", "retry", "invalid"}, {"retry"}, {"retry"}}, sent)
+	assert.Nil(t, errs[0])
+	assert.Nil(t, errs[1])
+	assert.Equal(t, structure.String("retry"), elems[1])
+	assert.Equal(t, gerrors.ErrorCode_GREMLIN_INVALID_QUERY, gerrors.Code(errs[2]))
+}
+
+func TestRetryBudget(t *testing.T) {
+	ctx := context.Background()
+	cli, err := NewClient(WithHostPort("ip:port"), WithRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond,
+		Budget: 50 * time.Millisecond}))
+	assert.NoError(t, err)
+	attempts := 0
+	cli.setklient(&TCtxClient{fn: func(ctx context.Context, req *bytegraph.GremlinQueryRequest) (*bytegraph.GremlinQueryResponse, error) {
+		attempts++
+		resp := &bytegraph.GremlinQueryResponse{}
+		if attempts == 1 {
+			mockAppendResult(resp, bytegraph.ErrorCode_RETRY, nil)
+			return resp, nil
+		}
+		// the retry is bounded by the budget
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}})
+
+	start := time.Now()
+	_, err = cli.Submit(ctx, "g.V()", "test")
+	assert.Equal(t, gerrors.ErrorCode_NETWORK_ERROR, gerrors.Code(err))
+	assert.Equal(t, 2, attempts)
+	assert.InDelta(t, 50*time.Millisecond, time.Since(start), float64(40*time.Millisecond))
+}
+
+func TestBatching(t *testing.T) {
+	ctx := context.Background()
+	cli, err := NewClient(WithHostPort("ip:port"), WithBatching(time.Second, 4))
+	assert.True(t, err == nil)
+	var mu sync.Mutex
+	var sent [][]string
+	cli.setklient(&TFuncClient{fn: func(req *bytegraph.GremlinQueryRequest) (*bytegraph.GremlinQueryResponse, error) {
+		mu.Lock()
+		sent = append(sent, req.Queries)
+		mu.Unlock()
+		resp := &bytegraph.GremlinQueryResponse{}
+		for _, query := range req.Queries {
+			if query == "invalid" {
+				mockAppendResult(resp, bytegraph.ErrorCode_GREMLIN_INVALID_QUERY, nil)
+				continue
+			}
+			mockAppendResult(resp, bytegraph.ErrorCode_SUCCESS, structure.String(query))
+		}
+		return resp, nil
+	}})
+
+	queries := []string{"a", "b", "invalid", "c"}
+	elems := make([]structure.Element, len(queries))
+	errs := make([]error, len(queries))
+	var wg sync.WaitGroup
+	for i := range queries {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			elems[i], errs[i] = cli.Submit(ctx, queries[i], "test")
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 1, len(sent))
+	assert.Equal(t, 4, len(sent[0]))
+	for i, query := range queries {
+		if query == "invalid" {
+			assert.Equal(t, gerrors.ErrorCode_GREMLIN_INVALID_QUERY, gerrors.Code(errs[i]))
+			continue
+		}
+		assert.Nil(t, errs[i])
+		assert.Equal(t, structure.String(query), elems[i])
+	}
+}
+
+func TestBatchingContext(t *testing.T) {
+	ctx := context.Background()
+	cli, err := NewClient(WithHostPort("ip:port"), WithBatching(50*time.Millisecond, 3))
+	assert.NoError(t, err)
+	var mu sync.Mutex
+	var sent [][]string
+	var routeKeys []string
+	canceled := make(chan struct{}, 1)
+	cli.setklient(&TCtxClient{fn: func(ctx context.Context, req *bytegraph.GremlinQueryRequest) (*bytegraph.GremlinQueryResponse, error) {
+		routeKey, _ := ctx.Value(RouteKey{}).(string)
+		mu.Lock()
+		sent = append(sent, req.Queries)
+		routeKeys = append(routeKeys, routeKey)
+		mu.Unlock()
+		if req.Queries[0] == "block" {
+			<-ctx.Done()
+			canceled <- struct{}{}
+			return nil, ctx.Err()
+		}
+		resp := &bytegraph.GremlinQueryResponse{}
+		for _, query := range req.Queries {
+			mockAppendResult(resp, bytegraph.ErrorCode_SUCCESS, structure.String(query))
+		}
+		return resp, nil
+	}})
+
+	// queries of different route keys are not batched, and the values of context are kept
+	var wg sync.WaitGroup
+	for _, key := range []string{"a", "b"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			elem, err := cli.Submit(context.WithValue(ctx, RouteKey{}, key), key, "test")
+			assert.NoError(t, err)
+			assert.Equal(t, structure.String(key), elem)
+		}(key)
+	}
+	wg.Wait()
+	assert.ElementsMatch(t, [][]string{{"a"}, {"b"}}, sent)
+	assert.ElementsMatch(t, []string{"a", "b"}, routeKeys)
+
+	// a query whose context ends before sending is dropped, without failing the others
+	sent = nil
+	shortCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, err := cli.Submit(shortCtx, "short", "test")
+		assert.Equal(t, gerrors.ErrorCode_NETWORK_ERROR, gerrors.Code(err))
+	}()
+	elem, err := cli.Submit(ctx, "long", "test")
+	assert.NoError(t, err)
+	assert.Equal(t, structure.String("long"), elem)
+	wg.Wait()
+	assert.Equal(t, [][]string{{"long"}}, sent)
+
+	// the request is canceled when no query waits for it
+	blockCtx, cancelBlock := context.WithCancel(ctx)
+	time.AfterFunc(100*time.Millisecond, cancelBlock)
+	_, err = cli.Submit(blockCtx, "block", "test")
+	assert.Error(t, err)
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("batch request is not canceled")
+	}
+}
+
+func TestBatchSubmitChunked(t *testing.T) {
+	ctx := context.Background()
+	cli, err := NewClient(WithHostPort("ip:port"))
+	assert.True(t, err == nil)
+	var mu sync.Mutex
+	var requests int
+	cli.setklient(&TFuncClient{fn: func(req *bytegraph.GremlinQueryRequest) (*bytegraph.GremlinQueryResponse, error) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		assert.True(t, len(req.Queries) <= 3)
+		resp := &bytegraph.GremlinQueryResponse{}
+		for _, query := range req.Queries {
+			mockAppendResult(resp, bytegraph.ErrorCode_SUCCESS, structure.String(query))
+		}
+		return resp, nil
+	}})
+
+	queries := []string{"0", "1", "2", "3", "4", "5", "6666666666", "7"}
+	var progress []int
+	elems, errs := cli.BatchSubmitChunked(ctx, queries, ChunkOptions{
+		MaxChunkSize:  3,
+		MaxChunkBytes: 8,
+		Parallelism:   2,
+		Progress: func(done, total int) {
+			assert.Equal(t, len(queries), total)
+			progress = append(progress, done)
+		},
+	}, "test")
+	// chunks: [0 1 2] [3 4 5] [6666666666] [7]
+	assert.Equal(t, 4, requests)
+	assert.Equal(t, 4, len(progress))
+	assert.Equal(t, len(queries), progress[len(progress)-1])
+	for i, query := range queries {
+		assert.Nil(t, errs[i])
+		assert.Equal(t, structure.String(query), elems[i])
+	}
+}
+
+type testUser struct {
+	Id    int64  `gremlin:"id"`
+	Type  int32  `gremlin:"type"`
+	Name  string `gremlin:"name"`
+	Power int64  `gremlin:"power"`
+}
+
+type testUserUpdate struct {
+	Id    int64       `gremlin:"id"`
+	Type  int32       `gremlin:"type"`
+	Name  *string     `gremlin:"name"`
+	Power *int64      `gremlin:"power"`
+	Extra interface{} `gremlin:"extra"`
+}
+
+type testRelative struct {
+	From     testUser `gremlin:"outV"`
+	To       testUser `gremlin:"inV"`
+	Type     string   `gremlin:"type"`
+	Relation string   `gremlin:"relation"`
+}
+
+func TestSaveVertexAndEdge(t *testing.T) {
+	ctx := context.Background()
+	cli, err := NewClient(WithHostPort("ip:port"), WithDefaultTable("test"))
+	assert.True(t, err == nil)
+	mocked := &TMockedClient{}
+	cli.setklient(mocked)
+
+	assert.Nil(t, cli.SaveVertex(ctx, &testUser{Id: 1, Type: 1001, Name: "段正淳"}))
+	assert.Equal(t, []string{`g.addV().property('id', 1).property('type', 1001).property('name', '段正淳').property('power', 0)`}, mocked.lastReq.Queries)
+
+	assert.Nil(t, cli.UpdateVertex(ctx, &testUser{Id: 1, Type: 1001, Power: 60}))
+	assert.Equal(t, []string{`g.V().has('id', 1).has('type', 1001).property('name', '').property('power', 60)`}, mocked.lastReq.Queries)
+
+	// nil pointer and nil interface fields are absent, a pointer to zero is written
+	power := int64(0)
+	assert.Nil(t, cli.UpdateVertex(ctx, &testUserUpdate{Id: 1, Type: 1001, Power: &power}))
+	assert.Equal(t, []string{`g.V().has('id', 1).has('type', 1001).property('power', 0)`}, mocked.lastReq.Queries)
+	assert.Nil(t, cli.UpdateVertex(ctx, &testUserUpdate{Id: 1, Type: 1001, Extra: false}))
+	assert.Equal(t, []string{`g.V().has('id', 1).has('type', 1001).property('extra', false)`}, mocked.lastReq.Queries)
+	mocked.lastReq = nil
+	assert.Nil(t, cli.UpdateVertex(ctx, &testUserUpdate{Id: 1, Type: 1001}))
+	assert.Nil(t, mocked.lastReq)
+
+	relative := &testRelative{From: testUser{Id: 1, Type: 1001}, To: testUser{Id: 2, Type: 1001}, Type: "relatives", Relation: "父女"}
+	assert.Nil(t, cli.SaveEdge(ctx, relative))
+	assert.Equal(t, []string{`g.addE('relatives').from(1, 1001).to(2, 1001).property('relation', '父女')`}, mocked.lastReq.Queries)
+
+	assert.Nil(t, cli.UpdateEdge(ctx, relative))
+	assert.Equal(t, []string{`g.V().has('id', 1).has('type', 1001).outE('relatives').where(inV().has('id', 2).has('type', 1001)).property('relation', '父女')`}, mocked.lastReq.Queries)
+
+	assert.NotNil(t, cli.SaveVertex(ctx, &struct{ Name string }{}))
+}
+
+func TestSubmitInto(t *testing.T) {
+	ctx := context.Background()
+	cli, err := NewClient(WithHostPort("ip:port"), WithDefaultTable("test"))
+	assert.True(t, err == nil)
+	cli.setklient(&TMockedClient{})
+
+	type like struct {
+		From testUser `gremlin:"outV"`
+		To   testUser `gremlin:"inV"`
+		Type string   `gremlin:"type"`
+	}
+	var edge like
+	assert.Nil(t, cli.SubmitInto(ctx, "g.V().has('id',1).has('type',1002).outE('like')", &edge))
+	assert.Equal(t, like{From: testUser{Id: 1, Type: 1002}, To: testUser{Id: 2, Type: 1002}, Type: "like"}, edge)
+	var edges []like
+	assert.Nil(t, cli.SubmitInto(ctx, "g.V().has('id',1).has('type',1002).outE('like')", &edges))
+	assert.Equal(t, []like{edge}, edges)
+
+	cli.setklient(&TFuncClient{fn: func(req *bytegraph.GremlinQueryRequest) (*bytegraph.GremlinQueryResponse, error) {
+		resp := &bytegraph.GremlinQueryResponse{}
+		for _, query := range req.Queries {
+			switch query {
+			case "count":
+				mockAppendResult(resp, bytegraph.ErrorCode_SUCCESS, structure.List{structure.Int64(3)})
+			case "empty":
+				mockAppendResult(resp, bytegraph.ErrorCode_SUCCESS, structure.List{})
+			default:
+				mockAppendResult(resp, bytegraph.ErrorCode_ELEM_NOT_EXIST, nil)
+			}
+		}
+		return resp, nil
+	}})
+	var count int64
+	var user testUser
+	errs := cli.BatchSubmitInto(ctx, []string{"count", "empty", "missing"}, []interface{}{&count, &user, &user})
+	assert.Nil(t, errs[0])
+	assert.Equal(t, int64(3), count)
+	assert.True(t, errors.Is(errs[1], gerrors.ErrEmptyResult))
+	assert.True(t, errors.Is(errs[2], gerrors.ErrElemNotExist))
+	assert.Equal(t, gerrors.ErrorCode_ELEM_NOT_EXIST, gerrors.Code(errs[2]))
+}
+
+func TestQuery(t *testing.T) {
+	ctx := context.Background()
+	cli, err := NewClient(WithHostPort("ip:port"), WithDefaultTable("test"))
+	assert.True(t, err == nil)
+	cli.setklient(&TFuncClient{fn: func(req *bytegraph.GremlinQueryRequest) (*bytegraph.GremlinQueryResponse, error) {
+		resp := &bytegraph.GremlinQueryResponse{}
+		switch req.Queries[0] {
+		case "count":
+			mockAppendResult(resp, bytegraph.ErrorCode_SUCCESS, structure.List{structure.Int64(3)})
+		case "names":
+			mockAppendResult(resp, bytegraph.ErrorCode_SUCCESS, structure.List{structure.String("a"), structure.String("b")})
+		case "empty":
+			mockAppendResult(resp, bytegraph.ErrorCode_SUCCESS, structure.List{})
+		case "name":
+			mockAppendResult(resp, bytegraph.ErrorCode_SUCCESS, structure.List{&structure.Property{Key: "name", Value: "a"}})
+		}
+		return resp, nil
+	}})
+
+	// a List of one property is bound as a whole, the same as SubmitInto
+	type named struct {
+		Name string `gremlin:"name"`
+	}
+	n, err := Query[named](ctx, cli, "name")
+	assert.Nil(t, err)
+	assert.Equal(t, named{Name: "a"}, n)
+	var into named
+	assert.Nil(t, cli.SubmitInto(ctx, "name", &into))
+	assert.Equal(t, n, into)
+
+	count, err := Query[int64](ctx, cli, "count")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), count)
+	_, err = Query[string](ctx, cli, "count")
+	assert.True(t, errors.Is(err, gerrors.ErrOrmTypeMismatch))
+	_, err = Query[int64](ctx, cli, "empty")
+	assert.True(t, errors.Is(err, gerrors.ErrEmptyResult))
+
+	names, err := QueryList[string](ctx, cli, "names")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b"}, names)
+	empty, err := QueryList[string](ctx, cli, "empty")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(empty))
+
+	// results decoded as ListStruct are unwrapped like List
+	cli.decodeUseStruct = true
+	count, err = Query[int64](ctx, cli, "count")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), count)
+	names, err = Query[[]string](ctx, cli, "names")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b"}, names)
+	_, err = Query[int64](ctx, cli, "empty")
+	assert.True(t, errors.Is(err, gerrors.ErrEmptyResult))
+	var c int64
+	assert.Nil(t, cli.SubmitInto(ctx, "count", &c))
+	assert.Equal(t, int64(3), c)
+}
+
+func TestProtocolError(t *testing.T) {
+	ctx := context.Background()
+	cli, err := NewClient(WithHostPort("ip:port"), WithDefaultTable("test"))
+	assert.True(t, err == nil)
+	cli.setklient(&TFuncClient{fn: func(req *bytegraph.GremlinQueryRequest) (*bytegraph.GremlinQueryResponse, error) {
+		resp := &bytegraph.GremlinQueryResponse{}
+		switch req.Queries[0] {
+		case "size":
+			mockAppendResult(resp, bytegraph.ErrorCode_SUCCESS, structure.String("1"))
+		case "missing":
+			resp.BatchErrCode = []bytegraph.ErrorCode{bytegraph.ErrorCode_SUCCESS, bytegraph.ErrorCode_RETRY}
+		case "table":
+			resp.ErrCode, resp.Desc = bytegraph.ErrorCode_TABLE_NOT_EXIST, "table not exist"
+		}
+		return resp, nil
+	}})
+
+	_, errs := cli.BatchSubmit(ctx, []string{"size", "size"})
+	assert.Equal(t, 2, len(errs))
+	var perr *ProtocolError
+	assert.True(t, errors.As(errs[1], &perr))
+	assert.Equal(t, gerrors.ErrorCode_PROTOCOL_ERROR, gerrors.Code(errs[1]))
+	assert.Equal(t, 1, len(perr.Response.BatchErrCode))
+
+	_, errs = cli.BatchSubmit(ctx, []string{"missing", "missing"})
+	assert.Equal(t, gerrors.ErrorCode_PROTOCOL_ERROR, gerrors.Code(errs[0]))
+	assert.Equal(t, gerrors.ErrorCode_RETRY, gerrors.Code(errs[1]))
+
+	_, err = cli.Submit(ctx, "table")
+	assert.Equal(t, gerrors.ErrorCode_TABLE_NOT_EXIST, gerrors.Code(err))
+}
+
+func TestInterceptors(t *testing.T) {
+	ctx := context.Background()
+	var order []string
+	var audited *Call
+	audit := func(next Endpoint) Endpoint {
+		return func(ctx context.Context, call *Call) {
+			order = append(order, "audit")
+			next(ctx, call)
+			audited = call
+		}
+	}
+	tag := func(next Endpoint) Endpoint {
+		return func(ctx context.Context, call *Call) {
+			order = append(order, "tag")
+			call.Request.Base = authBase(call.Request.Base, map[string]string{"tag": "test"})
+			next(ctx, call)
+		}
+	}
+	fault := func(next Endpoint) Endpoint {
+		return func(ctx context.Context, call *Call) {
+			if call.Queries()[0] == "fault" {
+				call.Errs = gerrors.DuplicateErr(gerrors.New(gerrors.ErrorCode_SERVICE_OVERLOAD), len(call.Queries()))
+				return
+			}
+			next(ctx, call)
+		}
+	}
+	cli, err := NewClient(WithHostPort("ip:port"), WithDefaultTable("test"), WithInterceptors(audit, tag), WithInterceptors(fault))
+	assert.True(t, err == nil)
+	mocked := &TMockedClient{}
+	cli.setklient(mocked)
+
+	_, err = cli.Submit(ctx, "g.V().has('id',1).has('type',1002).outE('like')")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"audit", "tag"}, order)
+	assert.Equal(t, "test", audited.Table)
+	assert.Equal(t, AuthType_DisableAuth, audited.AuthType)
+	assert.NotNil(t, audited.Response)
+	assert.Equal(t, 1, len(audited.Results))
+	assert.Contains(t, mocked.lastReq.Base.Extra[authentication.UserExtra], "test")
+
+	_, err = cli.Submit(ctx, "fault")
+	assert.Equal(t, gerrors.ErrorCode_SERVICE_OVERLOAD, gerrors.Code(err))
+	assert.Nil(t, audited.Response)
+}
+
+type recordTracer struct {
+	spans []*recordSpan
+}
+
+type recordSpan struct {
+	name   string
+	attrs  map[string]interface{}
+	events []string
+	err    error
+	ended  bool
+}
+
+func (t *recordTracer) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	span := &recordSpan{name: name, attrs: make(map[string]interface{})}
+	span.SetAttributes(attrs...)
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+func (t *recordTracer) Inject(ctx context.Context, carrier map[string]string) string {
+	carrier["trace"] = "trace-1"
+	return "trace-1"
+}
+
+func (s *recordSpan) SetAttributes(attrs ...Attribute) {
+	for _, attr := range attrs {
+		s.attrs[attr.Key] = attr.Value
+	}
+}
+
+func (s *recordSpan) AddEvent(name string, attrs ...Attribute) {
+	s.events = append(s.events, name)
+}
+
+func (s *recordSpan) RecordError(err error) {
+	s.err = err
+}
+
+func (s *recordSpan) End() {
+	s.ended = true
+}
+
+type refreshAuthClient struct {
+	MockedAuthClient
+	refreshed int
+}
+
+func (c *refreshAuthClient) Session(refresh bool) (string, error) {
+	if refresh {
+		c.refreshed++
+	}
+	return fmt.Sprintf("session_%d", c.refreshed), nil
+}
+
+func TestTracing(t *testing.T) {
+	ctx := context.Background()
+	tracer := &recordTracer{}
+	cli, err := NewClient(WithHostPort("ip:port"), WithDefaultTable("test"), WithUserPwd("user", "pwd"), WithTracer(tracer))
+	assert.True(t, err == nil)
+	cli.setAuthClient(&refreshAuthClient{})
+	mocked := &TMockedClient{}
+	var lastReq *bytegraph.GremlinQueryRequest
+	cli.setklient(&TFuncClient{fn: func(req *bytegraph.GremlinQueryRequest) (*bytegraph.GremlinQueryResponse, error) {
+		lastReq = req
+		if strings.Contains(req.Base.Extra[authentication.UserExtra], "session_0") {
+			return nil, authentication.ErrInvalidSession
+		}
+		return mocked.GremlinQuery(ctx, req)
+	}})
+
+	_, err = cli.Submit(ctx, "g.V().has('id',1).has('type',1002).outE('like')")
+	assert.Nil(t, err)
+	assert.Len(t, tracer.spans, 1)
+	span := tracer.spans[0]
+	assert.Equal(t, SpanName, span.name)
+	assert.True(t, span.ended)
+	assert.Nil(t, span.err)
+	assert.Equal(t, "test", span.attrs[AttrTable])
+	assert.Equal(t, int64(1), span.attrs[AttrBatchSize])
+	assert.Equal(t, []string{"g.V().has(?,?).has(?,?).outE(?)"}, span.attrs[AttrFingerprints])
+	assert.Equal(t, []int64{0}, span.attrs[AttrErrorCodes])
+	assert.Equal(t, []int64{0}, span.attrs[AttrCosts])
+	assert.Equal(t, []string{EventAuthRefresh}, span.events)
+	assert.Equal(t, "trace-1", lastReq.Base.LogID)
+	assert.Equal(t, "trace-1", lastReq.Base.Extra["trace"])
+
+	cli.setklient(&TFuncClient{fn: func(req *bytegraph.GremlinQueryRequest) (*bytegraph.GremlinQueryResponse, error) {
+		resp := &bytegraph.GremlinQueryResponse{}
+		mockAppendResult(resp, bytegraph.ErrorCode_SUCCESS, structure.String("1"))
+		mockAppendResult(resp, bytegraph.ErrorCode_ELEM_NOT_EXIST, nil)
+		return resp, nil
+	}})
+	_, errs := cli.BatchSubmit(ctx, []string{"g.V().count()", "g.V().has('id',2)"})
+	assert.Nil(t, errs[0])
+	span = tracer.spans[1]
+	assert.Equal(t, []int64{0, int64(gerrors.ErrorCode_ELEM_NOT_EXIST)}, span.attrs[AttrErrorCodes])
+	assert.Equal(t, []int64{1, 1}, span.attrs[AttrCosts])
+	assert.Equal(t, errs[1], span.err)
+}
+
+func TestMetrics(t *testing.T) {
+	ctx := context.Background()
+	registry := metrics.NewPrometheusRegistry()
+	cli, err := NewClient(WithHostPort("ip:port"), WithDefaultTable("test"), WithMetrics(registry))
+	assert.True(t, err == nil)
+	cli.setklient(&TFuncClient{fn: func(req *bytegraph.GremlinQueryRequest) (*bytegraph.GremlinQueryResponse, error) {
+		resp := &bytegraph.GremlinQueryResponse{}
+		mockAppendResult(resp, bytegraph.ErrorCode_SUCCESS, structure.String("1"))
+		mockAppendResult(resp, bytegraph.ErrorCode_ELEM_NOT_EXIST, nil)
+		return resp, nil
+	}})
+	_, errs := cli.BatchSubmit(ctx, []string{"g.V().count()", "g.V().has('id',2)"})
+	assert.Nil(t, errs[0])
+
+	// the host is filled by kitex middleware for real requests
+	cli, err = NewClient(WithHostPort("127.0.0.1:1"), WithDefaultTable("test"), WithMetrics(registry))
+	assert.True(t, err == nil)
+	_, err = cli.Submit(ctx, "g.V().count()")
+	assert.Equal(t, gerrors.ErrorCode_NETWORK_ERROR, gerrors.Code(err))
+
+	var b strings.Builder
+	_, err = registry.WriteTo(&b)
+	assert.NoError(t, err)
+	out := b.String()
+	assert.Contains(t, out, `bytegraph_client_queries_total{table="test",host="",code="SUCCESS"} 1`)
+	assert.Contains(t, out, `bytegraph_client_queries_total{table="test",host="",code="ELEM_NOT_EXIST"} 1`)
+	assert.Contains(t, out, `bytegraph_client_queries_total{table="test",host="127.0.0.1:1",code="NETWORK_ERROR"} 1`)
+	assert.Contains(t, out, `bytegraph_client_batch_size_count{table="test",host=""} 1`)
+	assert.Contains(t, out, `bytegraph_client_batch_size_sum{table="test",host=""} 2`)
+	assert.Contains(t, out, `bytegraph_client_query_cost_sum{table="test",host=""} 2`)
+	assert.Contains(t, out, `bytegraph_client_rpc_duration_seconds_count{table="test",host="127.0.0.1:1"} 1`)
+}
+
+func TestLogger(t *testing.T) {
+	ctx := context.Background()
+	var buf strings.Builder
+	logger := NewTextLogger(&buf, LevelDebug)
+	req := &bytegraph.GremlinQueryRequest{
+		Table:   "test",
+		Queries: []string{"g.V().count()"},
+		Base: authBase(nil, map[string]string{
+			authentication.PersistPwdKey:     "secret_pwd",
+			authentication.PersistSessionKey: "secret_session",
+			authentication.PersistUserKey:    "user",
+		}),
+	}
+	resp := &bytegraph.GremlinQueryResponse{}
+	mockAppendResult(resp, bytegraph.ErrorCode_SUCCESS, structure.String(strings.Repeat("x", 300)))
+	mw := newLogMiddleware(logger, 16)
+	err := mw(func(ctx context.Context, req, resp interface{}) error {
+		return nil
+	})(ctx, &bytegraph.ByteGraphServiceGremlinQueryArgs{Req: req}, &bytegraph.ByteGraphServiceGremlinQueryResult{Success: resp})
+	assert.NoError(t, err)
+	out := buf.String()
+	assert.Contains(t, out, "level=DEBUG msg=\"gremlin rpc\" table=test queries=[g.V().count()]")
+	assert.NotContains(t, out, "secret")
+	assert.Contains(t, out, redacted)
+	assert.Contains(t, out, "RPC_PERSIST_user")
+	assert.Contains(t, out, "...(307 bytes)")
+	assert.Contains(t, req.Base.Extra[authentication.UserExtra], "secret_pwd")
+
+	// debug events are only logged with DebugKey by a logger of higher level
+	buf.Reset()
+	logger = NewTextLogger(&buf, LevelWarn)
+	cli, err := NewClient(WithHostPort("ip:port"), WithDefaultTable("test"), WithLogger(logger))
+	assert.NoError(t, err)
+	cli.setklient(&TFuncClient{fn: func(req *bytegraph.GremlinQueryRequest) (*bytegraph.GremlinQueryResponse, error) {
+		return nil, errors.New("connection refused")
+	}})
+	mw = newLogMiddleware(logger, 16)
+	next := func(ctx context.Context, req, resp interface{}) error { return nil }
+	_ = mw(next)(ctx, &bytegraph.ByteGraphServiceGremlinQueryArgs{Req: req}, nil)
+	assert.Equal(t, "", buf.String())
+	_ = mw(next)(context.WithValue(ctx, DebugKey{}, true), &bytegraph.ByteGraphServiceGremlinQueryArgs{Req: req}, nil)
+	assert.Contains(t, buf.String(), "gremlin rpc")
+
+	buf.Reset()
+	_, err = cli.Submit(ctx, "g.V().count()")
+	assert.Equal(t, gerrors.ErrorCode_NETWORK_ERROR, gerrors.Code(err))
+	assert.Contains(t, buf.String(), `level=WARN msg="gremlin request failed" table=test host="" batch_size=1 err=`)
+	assert.Contains(t, buf.String(), "connection refused")
+
+	// the default logger keeps silent unless DebugKey is in the context
+	logger = newDefaultLogger()
+	for _, level := range []Level{LevelDebug, LevelInfo, LevelWarn, LevelError} {
+		assert.False(t, logger.Enabled(ctx, level))
+	}
+	assert.True(t, logger.Enabled(context.WithValue(ctx, DebugKey{}, true), LevelDebug))
+}
+
+type slogLevel int
+
+type fakeSlog struct {
+	level slogLevel
+	logs  []string
+}
+
+func (l *fakeSlog) Enabled(ctx context.Context, level slogLevel) bool {
+	return level >= l.level
+}
+
+func (l *fakeSlog) Log(ctx context.Context, level slogLevel, msg string, args ...interface{}) {
+	l.logs = append(l.logs, fmt.Sprint(level, msg, args))
+}
+
+func TestSlogLogger(t *testing.T) {
+	slog := &fakeSlog{level: slogLevel(LevelInfo)}
+	logger := NewSlogLogger[slogLevel](slog)
+	assert.False(t, logger.Enabled(context.Background(), LevelDebug))
+	assert.True(t, logger.Enabled(context.Background(), LevelWarn))
+	logger.Log(context.Background(), LevelWarn, "msg", Attribute{Key: "k", Value: "v"}, Attribute{Key: "n", Value: int64(1)})
+	assert.Equal(t, []string{"4msg[k v n 1]"}, slog.logs)
+}
+
+func TestSlowQueryLog(t *testing.T) {
+	ctx := context.Background()
+	var records []*SlowQuery
+	var mu sync.Mutex
+	handler := func(ctx context.Context, record *SlowQuery) {
+		mu.Lock()
+		defer mu.Unlock()
+		records = append(records, record)
+	}
+	mocked := &TFuncClient{fn: func(req *bytegraph.GremlinQueryRequest) (*bytegraph.GremlinQueryResponse, error) {
+		resp := &bytegraph.GremlinQueryResponse{}
+		for _, query := range req.Queries {
+			if query == "sleep" {
+				time.Sleep(20 * time.Millisecond)
+			}
+			mockAppendResult(resp, bytegraph.ErrorCode_SUCCESS, structure.String(query))
+		}
+		resp.Costs[0] = 100
+		return resp, nil
+	}}
+
+	cli, err := NewClient(WithHostPort("ip:port"), WithDefaultTable("test"),
+		WithSlowQueryLog(SlowQueryOptions{CostThreshold: 50, Handler: handler}))
+	assert.NoError(t, err)
+	cli.setklient(mocked)
+	_, errs := cli.BatchSubmit(ctx, []string{"g.V().has('id', 1)", "g.V().has('id', 2)"})
+	assert.Nil(t, errs[0])
+	assert.Len(t, records, 1)
+	assert.Equal(t, "g.V().has(?, ?)", records[0].Fingerprint)
+	assert.Equal(t, "test", records[0].Table)
+	assert.Equal(t, int64(100), records[0].Cost)
+	assert.Equal(t, 2, records[0].BatchSize)
+	assert.Equal(t, 25, records[0].ResultSize)
+	assert.Equal(t, gerrors.ErrorCode_SUCCESS, records[0].ErrCode)
+
+	records = nil
+	cli, err = NewClient(WithHostPort("ip:port"), WithDefaultTable("test"),
+		WithSlowQueryLog(SlowQueryOptions{LatencyThreshold: 10 * time.Millisecond, MaxPerSecond: 2, Handler: handler}))
+	assert.NoError(t, err)
+	cli.setklient(mocked)
+	_, errs = cli.BatchSubmit(ctx, []string{"g.V()", "g.E()"})
+	assert.Nil(t, errs[0])
+	assert.Len(t, records, 0)
+	_, errs = cli.BatchSubmit(ctx, []string{"sleep", "g.V()", "g.E()"})
+	assert.Nil(t, errs[0])
+	assert.Len(t, records, 2)
+	assert.True(t, records[0].Latency >= 20*time.Millisecond)
+	assert.Equal(t, records[0].Latency, records[0].RPCLatency+records[0].DecodeLatency)
+
+	// logged by default
+	var buf strings.Builder
+	cli, err = NewClient(WithHostPort("ip:port"), WithDefaultTable("test"), WithLogger(NewTextLogger(&buf, LevelInfo)),
+		WithSlowQueryLog(SlowQueryOptions{CostThreshold: 50}))
+	assert.NoError(t, err)
+	cli.setklient(mocked)
+	_, err = cli.Submit(ctx, "g.V().has('id', 1)")
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), `level=WARN msg="slow query" fingerprint="g.V().has(?, ?)" table=test`)
+
+	// not logged if the logger disables warnings
+	buf.Reset()
+	cli, err = NewClient(WithHostPort("ip:port"), WithDefaultTable("test"), WithLogger(NewTextLogger(&buf, LevelError)),
+		WithSlowQueryLog(SlowQueryOptions{CostThreshold: 50}))
+	assert.NoError(t, err)
+	cli.setklient(mocked)
+	_, err = cli.Submit(ctx, "g.V().has('id', 1)")
+	assert.Nil(t, err)
+	assert.Equal(t, "", buf.String())
+}
+
+func TestLimit(t *testing.T) {
+	ctx := context.Background()
+	release := make(chan struct{})
+	mocked := &TFuncClient{fn: func(req *bytegraph.GremlinQueryRequest) (*bytegraph.GremlinQueryResponse, error) {
+		resp := &bytegraph.GremlinQueryResponse{}
+		for _, query := range req.Queries {
+			if query == "block" {
+				<-release
+			}
+			mockAppendResult(resp, bytegraph.ErrorCode_SUCCESS, structure.String(query))
+		}
+		return resp, nil
+	}}
+
+	// fail fast by qps of table
+	cli, err := NewClient(WithHostPort("ip:port"), WithDefaultTable("a"),
+		WithTableLimit("a", Limit{QPS: 1, Burst: 2, Mode: LimitModeFailFast}))
+	assert.NoError(t, err)
+	cli.setklient(mocked)
+	_, errs := cli.BatchSubmit(ctx, []string{"g.V()", "g.V()"})
+	assert.Equal(t, []error{nil, nil}, errs)
+	_, err = cli.Submit(ctx, "g.V()")
+	assert.Equal(t, gerrors.ErrorCode_CLIENT_LIMITED, gerrors.Code(err))
+	assert.ErrorIs(t, err, gerrors.ErrRateLimited)
+	_, err = cli.Submit(ctx, "g.V()", "b")
+	assert.NoError(t, err)
+
+	// block by global qps, or fail if the deadline is too close
+	cli, err = NewClient(WithHostPort("ip:port"), WithDefaultTable("a"), WithLimit(Limit{QPS: 50, Burst: 1}))
+	assert.NoError(t, err)
+	cli.setklient(mocked)
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		_, err = cli.Submit(ctx, "g.V()")
+		assert.NoError(t, err)
+	}
+	assert.True(t, time.Since(start) >= 50*time.Millisecond)
+	shortCtx, cancel := context.WithTimeout(ctx, time.Millisecond)
+	defer cancel()
+	_, err = cli.Submit(shortCtx, "g.V()")
+	assert.ErrorIs(t, err, gerrors.ErrRateLimited)
+
+	// in-flight requests
+	cli, err = NewClient(WithHostPort("ip:port"), WithDefaultTable("a"), WithLimit(Limit{MaxInFlight: 1, Mode: LimitModeFailFast}))
+	assert.NoError(t, err)
+	cli.setklient(mocked)
+	done := make(chan error)
+	go func() {
+		_, err := cli.Submit(ctx, "block")
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	_, err = cli.Submit(ctx, "g.V()")
+	assert.ErrorIs(t, err, gerrors.ErrConcurrencyLimited)
+	close(release)
+	assert.NoError(t, <-done)
+	_, err = cli.Submit(ctx, "g.V()")
+	assert.NoError(t, err)
+
+	// no tokens are taken by a request rejected for in-flight slots
+	release = make(chan struct{})
+	cli, err = NewClient(WithHostPort("ip:port"), WithDefaultTable("a"),
+		WithTableLimit("a", Limit{QPS: 0.001, Burst: 2, Mode: LimitModeFailFast}),
+		WithLimit(Limit{MaxInFlight: 1, Mode: LimitModeFailFast}))
+	assert.NoError(t, err)
+	cli.setklient(mocked)
+	go func() {
+		_, err := cli.Submit(ctx, "block")
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	_, err = cli.Submit(ctx, "g.V()")
+	assert.ErrorIs(t, err, gerrors.ErrConcurrencyLimited)
+	close(release)
+	assert.NoError(t, <-done)
+	_, err = cli.Submit(ctx, "g.V()")
+	assert.NoError(t, err)
+
+	// the tokens of table are refunded if the global limit rejects
+	cli, err = NewClient(WithHostPort("ip:port"), WithDefaultTable("a"),
+		WithTableLimit("a", Limit{QPS: 0.001, Burst: 1, Mode: LimitModeFailFast}), WithLimit(Limit{QPS: 20, Burst: 1}))
+	assert.NoError(t, err)
+	cli.setklient(mocked)
+	_, err = cli.Submit(ctx, "g.V()", "b")
+	assert.NoError(t, err)
+	shortCtx, cancel = context.WithTimeout(ctx, time.Millisecond)
+	defer cancel()
+	_, err = cli.Submit(shortCtx, "g.V()")
+	assert.ErrorIs(t, err, gerrors.ErrRateLimited)
+	_, err = cli.Submit(ctx, "g.V()")
+	assert.NoError(t, err)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	var changes []string
+	onStateChange := func(scope BreakerScope, name string, from, to BreakerState) {
+		changes = append(changes, fmt.Sprintf("%d %s %s->%s", scope, name, from, to))
+	}
+	code := bytegraph.ErrorCode_SERVICE_OVERLOAD
+	calls := 0
+	cli, err := NewClient(WithHostPort("ip:port"), WithDefaultTable("test"), WithCircuitBreaker(BreakerOptions{
+		Scopes:        BreakerScopeTable,
+		MinRequests:   2,
+		OpenTimeout:   50 * time.Millisecond,
+		OnStateChange: onStateChange,
+	}))
+	assert.NoError(t, err)
+	cli.setklient(&TFuncClient{fn: func(req *bytegraph.GremlinQueryRequest) (*bytegraph.GremlinQueryResponse, error) {
+		calls++
+		resp := &bytegraph.GremlinQueryResponse{}
+		if code == bytegraph.ErrorCode_SUCCESS {
+			mockAppendResult(resp, code, structure.String("1"))
+		} else {
+			mockAppendResult(resp, code, nil)
+		}
+		return resp, nil
+	}})
+
+	for i := 0; i < 2; i++ {
+		_, err = cli.Submit(ctx, "g.V()")
+		assert.Equal(t, gerrors.ErrorCode_SERVICE_OVERLOAD, gerrors.Code(err))
+	}
+	_, err = cli.Submit(ctx, "g.V()")
+	assert.Equal(t, gerrors.ErrorCode_CIRCUIT_OPEN, gerrors.Code(err))
+	assert.ErrorIs(t, err, gerrors.ErrCircuitOpen)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, []string{"1 test closed->open"}, changes)
+
+	// a failed trial opens it again
+	time.Sleep(60 * time.Millisecond)
+	_, err = cli.Submit(ctx, "g.V()")
+	assert.Equal(t, gerrors.ErrorCode_SERVICE_OVERLOAD, gerrors.Code(err))
+	_, err = cli.Submit(ctx, "g.V()")
+	assert.Equal(t, gerrors.ErrorCode_CIRCUIT_OPEN, gerrors.Code(err))
+
+	code = bytegraph.ErrorCode_SUCCESS
+	time.Sleep(60 * time.Millisecond)
+	_, err = cli.Submit(ctx, "g.V()")
+	assert.NoError(t, err)
+	_, err = cli.Submit(ctx, "g.V()")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, calls)
+	assert.Equal(t, []string{"1 test closed->open", "1 test open->half-open", "1 test half-open->open",
+		"1 test open->half-open", "1 test half-open->closed"}, changes)
+
+	// host breakers are applied when the host is picked
+	changes = nil
+	cli, err = NewClient(WithHostPort("127.0.0.1:1"), WithDefaultTable("test"), WithCircuitBreaker(BreakerOptions{
+		Scopes:        BreakerScopeHost,
+		MinRequests:   1,
+		OnStateChange: onStateChange,
+	}))
+	assert.NoError(t, err)
+	_, err = cli.Submit(ctx, "g.V()")
+	assert.Equal(t, gerrors.ErrorCode_NETWORK_ERROR, gerrors.Code(err))
+	_, err = cli.Submit(ctx, "g.V()")
+	assert.Equal(t, gerrors.ErrorCode_CIRCUIT_OPEN, gerrors.Code(err))
+	assert.Equal(t, []string{"2 127.0.0.1:1 closed->open"}, changes)
+
+	// each trial of a host gets one result in the instance middleware, a lost one expires after OpenTimeout
+	changes = nil
+	bs := newBreakers(BreakerOptions{Scopes: BreakerScopeHost, MinRequests: 1, OpenTimeout: 20 * time.Millisecond,
+		OnStateChange: onStateChange})
+	ri := rpcinfo.NewRPCInfo(nil, rpcinfo.NewEndpointInfo("", "", utils.NewNetAddr("tcp", "10.0.0.1:9000"), nil), nil, nil, nil)
+	hostCtx := rpcinfo.NewCtxWithRPCInfo(ctx, ri)
+	args := &bytegraph.ByteGraphServiceGremlinQueryArgs{Req: &bytegraph.GremlinQueryRequest{Queries: []string{"g.V()"}}}
+	send := func(ctx context.Context, err error) error {
+		return bs.instanceMiddleware(func(ctx context.Context, req, resp interface{}) error {
+			if err == nil {
+				result := &bytegraph.GremlinQueryResponse{}
+				mockAppendResult(result, bytegraph.ErrorCode_SUCCESS, structure.String("1"))
+				resp.(*bytegraph.ByteGraphServiceGremlinQueryResult).Success = result
+			}
+			return err
+		})(ctx, args, &bytegraph.ByteGraphServiceGremlinQueryResult{})
+	}
+	assert.Error(t, send(hostCtx, errors.New("connection refused")))
+	assert.ErrorIs(t, send(hostCtx, nil), kerrors.ErrCircuitBreak)
+	time.Sleep(30 * time.Millisecond)
+	// the trial canceled by caller is given back
+	canceledCtx, cancel := context.WithCancel(hostCtx)
+	cancel()
+	assert.ErrorIs(t, send(canceledCtx, context.Canceled), context.Canceled)
+	// the result of this trial is dropped
+	assert.True(t, bs.allow(BreakerScopeHost, "10.0.0.1:9000"))
+	assert.ErrorIs(t, send(hostCtx, nil), kerrors.ErrCircuitBreak)
+	time.Sleep(30 * time.Millisecond)
+	assert.NoError(t, send(hostCtx, nil))
+	assert.Equal(t, []string{"2 10.0.0.1:9000 closed->open", "2 10.0.0.1:9000 open->half-open",
+		"2 10.0.0.1:9000 half-open->closed"}, changes)
+}
+
+func TestAdaptiveLimit(t *testing.T) {
+	ctx := context.Background()
+	var limitsMu sync.Mutex
+	var limits []int
+	getLimits := func() []int {
+		limitsMu.Lock()
+		defer limitsMu.Unlock()
+		return append([]int(nil), limits...)
+	}
+	code := bytegraph.ErrorCode_PART_OVER_QUOTA
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	cli, err := NewClient(WithHostPort("ip:port"), WithDefaultTable("test"), WithAdaptiveLimit(AdaptiveLimitOptions{
+		InitialLimit: 4,
+		MaxLimit:     5,
+		OnLimitChange: func(table string, limit int) {
+			limitsMu.Lock()
+			defer limitsMu.Unlock()
+			limits = append(limits, limit)
+		},
+	}))
+	assert.NoError(t, err)
+	cli.setklient(&TFuncClient{fn: func(req *bytegraph.GremlinQueryRequest) (*bytegraph.GremlinQueryResponse, error) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		resp := &bytegraph.GremlinQueryResponse{}
+		for _, query := range req.Queries {
+			if strings.Contains(query, "addV") && code != bytegraph.ErrorCode_SUCCESS {
+				mockAppendResult(resp, code, nil)
+			} else {
+				mockAppendResult(resp, bytegraph.ErrorCode_SUCCESS, structure.String("1"))
+			}
+		}
+		return resp, nil
+	}})
+
+	write := "g.addV().property('id', 1).property('type', 1001)"
+	_, err = cli.Submit(ctx, write)
+	assert.Equal(t, gerrors.ErrorCode_PART_OVER_QUOTA, gerrors.Code(err))
+	_, err = cli.Submit(ctx, write)
+	assert.Equal(t, gerrors.ErrorCode_PART_OVER_QUOTA, gerrors.Code(err))
+	_, err = cli.Submit(ctx, write)
+	assert.Equal(t, []int{2, 1}, getLimits())
+
+	// reads are not limited
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := cli.Submit(ctx, "g.V().has('id', 1).has('type', 1001).properties()")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.True(t, maxInFlight > 1)
+
+	// writes wait for the limit, which is raised on success
+	code = bytegraph.ErrorCode_SUCCESS
+	maxInFlight = 0
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := cli.Submit(ctx, write)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.True(t, maxInFlight <= 2)
+	// each success raises the limit by 1/limit, so it grows by one step at a time within the bounds
+	raised := getLimits()[2:]
+	assert.NotEmpty(t, raised)
+	prev := 1
+	for _, limit := range raised {
+		assert.Equal(t, prev+1, limit)
+		assert.True(t, limit <= 5)
+		prev = limit
+	}
+
+	// the waiting is canceled by ctx
+	blocked := make(chan struct{})
+	cli.setklient(&TFuncClient{fn: func(req *bytegraph.GremlinQueryRequest) (*bytegraph.GremlinQueryResponse, error) {
+		<-blocked
+		resp := &bytegraph.GremlinQueryResponse{}
+		mockAppendResult(resp, bytegraph.ErrorCode_SUCCESS, structure.String("1"))
+		return resp, nil
+	}})
+	for i := 0; i < 3; i++ {
+		go func() {
+			_, _ = cli.Submit(ctx, write)
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = cli.Submit(timeoutCtx, write)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	close(blocked)
+}
+
+func TestHedging(t *testing.T) {
+	ctx := context.Background()
+	var mu sync.Mutex
+	var hosts []string
+	var canceled int
+	slow := true
+	mocked := &TCtxClient{fn: func(ctx context.Context, req *bytegraph.GremlinQueryRequest) (*bytegraph.GremlinQueryResponse, error) {
+		mu.Lock()
+		hostPort, _ := ctx.Value(hostPortKey{}).(string)
+		hosts = append(hosts, hostPort)
+		first := slow
+		slow = false
+		mu.Unlock()
+		if first {
+			// the slow node
+			select {
+			case <-ctx.Done():
+				mu.Lock()
+				canceled++
+				mu.Unlock()
+				return nil, ctx.Err()
+			case <-time.After(time.Second):
+			}
+		}
+		resp := &bytegraph.GremlinQueryResponse{}
+		mockAppendResult(resp, bytegraph.ErrorCode_SUCCESS, structure.String(req.Queries[0]))
+		return resp, nil
+	}}
+	cli, err := NewClient(WithHostPort("a:1", "b:1"), WithDefaultTable("test"),
+		WithHedging(HedgeOptions{MaxDelay: 10 * time.Millisecond, BudgetBurst: 1, BudgetRatio: 0.01}))
+	assert.NoError(t, err)
+	cli.setklient(mocked)
+
+	start := time.Now()
+	elem, err := cli.Submit(ctx, "g.V().has('id', 1).has('type', 1001).properties()")
+	assert.NoError(t, err)
+	assert.Equal(t, structure.String("g.V().has('id', 1).has('type', 1001).properties()"), elem)
+	assert.True(t, time.Since(start) < 500*time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	mu.Lock()
+	assert.Len(t, hosts, 2)
+	assert.NotEqual(t, hosts[0], hosts[1])
+	assert.Equal(t, 1, canceled)
+	mu.Unlock()
+
+	// writes are not hedged
+	hosts = nil
+	_, err = cli.Submit(ctx, "g.addV().property('id', 1).property('type', 1001)")
+	assert.NoError(t, err)
+	assert.Len(t, hosts, 1)
+
+	// the budget is used up
+	hosts = nil
+	_, err = cli.Submit(ctx, "g.V().has('id', 1).has('type', 1001).properties()")
+	assert.NoError(t, err)
+	assert.Len(t, hosts, 1)
+}
+
+func TestHedgeDelay(t *testing.T) {
+	h := newHedger(HedgeOptions{Percentile: 0.9, MaxDelay: time.Second}, nil)
+	assert.Equal(t, time.Second, h.currentDelay())
+	for i := 1; i <= hedgeMinSamples; i++ {
+		h.observe(time.Duration(i) * time.Millisecond)
+	}
+	assert.Equal(t, 18*time.Millisecond, h.currentDelay())
+	for i := 0; i < hedgeRecompute; i++ {
+		h.observe(time.Hour)
+	}
+	assert.Equal(t, time.Second, h.currentDelay())
+}
+
+func TestResolveInterval(t *testing.T) {
+	var mu sync.Mutex
+	hosts, lookupErr := []string{"10.0.0.2", "10.0.0.1"}, error(nil)
+	setHosts := func(h []string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		hosts, lookupErr = h, err
+	}
+	defer func(fn func(context.Context, string) ([]string, error)) { lookupHost = fn }(lookupHost)
+	lookupHost = func(ctx context.Context, host string) ([]string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, "graph.test", host)
+		return append([]string(nil), hosts...), lookupErr
+	}
+
+	cli, err := NewClient(WithServiceNamePort("graph.test", 9000), WithResolveInterval(10*time.Millisecond))
+	assert.NoError(t, err)
+	defer cli.Close()
+	assert.Equal(t, []string{"10.0.0.1:9000", "10.0.0.2:9000"}, cli.getHostPorts())
+
+	setHosts([]string{"10.0.0.3"}, nil)
+	assert.Eventually(t, func() bool {
+		return equalStrings([]string{"10.0.0.3:9000"}, cli.getHostPorts())
+	}, time.Second, 5*time.Millisecond)
+	res, err := newHostResolver(NewStaticResolver(), &Options{}).kitexResolver(cli).Resolve(context.Background(), "")
+	assert.NoError(t, err)
+	assert.Len(t, res.Instances, 1)
+	assert.Equal(t, "10.0.0.3:9000", res.Instances[0].Address().String())
+
+	// the previous hosts are kept on failure
+	setHosts(nil, errors.New("no such host"))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, []string{"10.0.0.3:9000"}, cli.getHostPorts())
+
+	assert.NoError(t, cli.Close())
+	time.Sleep(20 * time.Millisecond)
+	setHosts([]string{"10.0.0.4"}, nil)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, []string{"10.0.0.3:9000"}, cli.getHostPorts())
+
+	setHosts(nil, nil)
+	_, err = NewClient(WithServiceNamePort("graph.test", 9000))
+	assert.Error(t, err)
+}
+
+func TestResolvers(t *testing.T) {
+	ctx := context.Background()
+	hostPorts, err := NewStaticResolver("10.0.0.1:9000").Resolve(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1:9000"}, hostPorts)
+
+	defer func(fn func(context.Context, string) ([]string, error)) { lookupHost = fn }(lookupHost)
+	lookupHost = func(ctx context.Context, host string) ([]string, error) {
+		return []string{"10.0.0.1", "fe80::1"}, nil
+	}
+	hostPorts, err = NewDNSResolver("graph.test", 9000).Resolve(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1:9000", "[fe80::1]:9000"}, hostPorts)
+
+	defer func(fn func(context.Context, string, string, string) (string, []*net.SRV, error)) { lookupSRV = fn }(lookupSRV)
+	lookupSRV = func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+		assert.Equal(t, []string{"graph", "tcp", "test"}, []string{service, proto, name})
+		return "_graph._tcp.test.", []*net.SRV{{Target: "node1.test.", Port: 9001}, {Target: "node2.test.", Port: 9002}}, nil
+	}
+	hostPorts, err = NewSRVResolver("graph", "tcp", "test").Resolve(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"node1.test:9001", "node2.test:9002"}, hostPorts)
+
+	path := filepath.Join(t.TempDir(), "hosts")
+	assert.NoError(t, os.WriteFile(path, []byte("# graph\n10.0.0.1:9000\n\n  10.0.0.2:9000\n"), 0644))
+	hostPorts, err = NewFileResolver(path).Resolve(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1:9000", "10.0.0.2:9000"}, hostPorts)
+	assert.NoError(t, os.WriteFile(path, []byte("10.0.0.1\n"), 0644))
+	_, err = NewFileResolver(path).Resolve(ctx)
+	assert.Error(t, err)
+
+	// the file is read again by default
+	assert.NoError(t, os.WriteFile(path, []byte("10.0.0.1:9000\n"), 0644))
+	fr := NewFileResolver(path)
+	fr.(*fileResolver).interval = 10 * time.Millisecond
+	fileCli, err := NewClient(WithResolver(fr))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1:9000"}, fileCli.getHostPorts())
+	assert.NoError(t, os.WriteFile(path, []byte("10.0.0.2:9000\n"), 0644))
+	assert.Eventually(t, func() bool {
+		return equalStrings([]string{"10.0.0.2:9000"}, fileCli.getHostPorts())
+	}, time.Second, 5*time.Millisecond)
+	assert.NoError(t, fileCli.Close())
+
+	// the hosts of auth service are derived from the resolved ones
+	resolver := newHostResolver(ResolverFunc(func(ctx context.Context) ([]string, error) {
+		return []string{"10.0.0.2:9000", "10.0.0.1:9000"}, nil
+	}), &Options{HostPorts: []string{"10.0.0.3:9000"}, authPort: 6287, authType: AuthType_PasswordSha256})
+	hostPorts, authHostPorts, err := resolver.lookup(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.3:9000", "10.0.0.1:9000", "10.0.0.2:9000"}, hostPorts)
+	assert.Equal(t, []string{"10.0.0.3:6287", "10.0.0.1:6287", "10.0.0.2:6287"}, authHostPorts)
+
+	cli, err := NewClient(WithResolver(NewStaticResolver()))
+	assert.Nil(t, cli)
+	assert.Error(t, err)
+
+	// the resolver is called only once without WithResolveInterval
+	var calls int32
+	cli, err = NewClient(WithResolver(ResolverFunc(func(ctx context.Context) ([]string, error) {
+		atomic.AddInt32(&calls, 1)
+		return []string{"10.0.0.1:9000"}, nil
+	})))
+	assert.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.NoError(t, cli.Close())
+}
+
+func TestHashRouting(t *testing.T) {
+	ctx := context.Background()
+	var hosts []string
+	mocked := &TCtxClient{fn: func(ctx context.Context, req *bytegraph.GremlinQueryRequest) (*bytegraph.GremlinQueryResponse, error) {
+		hostPort, _ := ctx.Value(hostPortKey{}).(string)
+		hosts = append(hosts, hostPort)
+		resp := &bytegraph.GremlinQueryResponse{}
+		mockAppendResult(resp, bytegraph.ErrorCode_SUCCESS, structure.String("1"))
+		return resp, nil
+	}}
+	cli, err := NewClient(WithHostPort("10.0.0.1:9000", "10.0.0.2:9000", "10.0.0.3:9000"), WithDefaultTable("test"), WithHashRouting(0))
+	assert.NoError(t, err)
+	cli.setklient(mocked)
+
+	for _, traversal := range []*gremlin.Traversal{gremlin.G().V(1, 1001).Properties(), gremlin.G().V(1, 1001).OutE("like").Count()} {
+		_, err = cli.Submit(WithTraversalRouteKey(ctx, traversal), traversal.String())
+		assert.NoError(t, err)
+	}
+	_, err = cli.Submit(context.WithValue(ctx, RouteKey{}, "1:1001"), "g.V().count()")
+	assert.NoError(t, err)
+	assert.NoError(t, cli.SaveVertex(ctx, &testUser{Id: 1, Type: 1001, Name: "a"}))
+	// query text is not parsed for a key
+	_, err = cli.Submit(ctx, "g.V().has('id', 1).has('type', 1001).properties()")
+	assert.NoError(t, err)
+	_, err = cli.Submit(WithTraversalRouteKey(ctx, gremlin.G().V().Count()), "g.V().count()")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, hosts[0])
+	assert.Equal(t, []string{hosts[0], hosts[0], hosts[0], hosts[0], "", ""}, hosts)
+
+	// keys are spread over hosts, and only the keys of the new host move when it's added
+	ring := newHashRing([]string{"10.0.0.1:9000", "10.0.0.2:9000", "10.0.0.3:9000"}, DefaultVirtualNodes)
+	grown := newHashRing([]string{"10.0.0.1:9000", "10.0.0.2:9000", "10.0.0.3:9000", "10.0.0.4:9000"}, DefaultVirtualNodes)
+	shares := make(map[string]int)
+	moved := 0
+	for i := 0; i < 10000; i++ {
+		key := fmt.Sprintf("%d:1001", i)
+		owner := ring.get(key)
+		shares[owner]++
+		if newOwner := grown.get(key); newOwner != owner {
+			moved++
+			assert.Equal(t, "10.0.0.4:9000", newOwner)
+		}
+	}
+	for _, share := range shares {
+		assert.InDelta(t, 3333, share, 700)
+	}
+	assert.InDelta(t, 2500, moved, 700)
+}
+
+func TestHealthCheck(t *testing.T) {
+	ctx := context.Background()
+	var mu sync.Mutex
+	dead := make(map[string]bool)
+	setDead := func(hostPort string, d bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		dead[hostPort] = d
+	}
+	var hosts []string
+	mocked := &TCtxClient{fn: func(ctx context.Context, req *bytegraph.GremlinQueryRequest) (*bytegraph.GremlinQueryResponse, error) {
+		hostPort, _ := ctx.Value(hostPortKey{}).(string)
+		if p, ok := ctx.Value(peerKey{}).(*peer); ok {
+			p.addr = hostPort
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if ctx.Value(probeKey{}) == nil {
+			hosts = append(hosts, hostPort)
+		}
+		if dead[hostPort] {
+			return nil, errors.New("connection refused")
+		}
+		resp := &bytegraph.GremlinQueryResponse{}
+		mockAppendResult(resp, bytegraph.ErrorCode_SUCCESS, structure.String("1"))
+		return resp, nil
+	}}
+	var changes []string
+	all := []string{"10.0.0.1:9000", "10.0.0.2:9000", "10.0.0.3:9000"}
+	cli, err := NewClient(WithHostPort(all...), WithDefaultTable("test"), WithHashRouting(0),
+		WithHealthCheck(HealthCheckOptions{
+			ConsecutiveFailures: 2,
+			ProbeInterval:       10 * time.Millisecond,
+			OnStateChange: func(hostPort string, healthy bool) {
+				mu.Lock()
+				defer mu.Unlock()
+				changes = append(changes, fmt.Sprintf("%s %v", hostPort, healthy))
+			},
+		}))
+	assert.NoError(t, err)
+	defer cli.Close()
+	cli.setklient(mocked)
+
+	// find the keys owned by different hosts
+	ring := newHashRing(all, DefaultVirtualNodes)
+	keys := make(map[string]string)
+	for i := 0; len(keys) < len(all); i++ {
+		key := fmt.Sprintf("%d:1001", i)
+		if _, ok := keys[ring.get(key)]; !ok {
+			keys[ring.get(key)] = key
+		}
+	}
+	submit := func(hostPort string) error {
+		_, err := cli.Submit(context.WithValue(ctx, RouteKey{}, keys[hostPort]), "g.V().count()")
+		return err
+	}
+
+	setDead(all[0], true)
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, gerrors.ErrorCode_NETWORK_ERROR, gerrors.Code(submit(all[0])))
+	}
+	assert.Equal(t, []string{all[0] + " false"}, changes)
+	// the key moves to other hosts
+	hosts = nil
+	assert.NoError(t, submit(all[0]))
+	assert.NotEqual(t, all[0], hosts[0])
+
+	// the ejected host is rejected when picked by load balancer
+	ri := rpcinfo.NewRPCInfo(nil, rpcinfo.NewEndpointInfo("", "", utils.NewNetAddr("tcp", all[0]), nil), nil, nil, nil)
+	err = cli.health.instanceMiddleware(func(ctx context.Context, req, resp interface{}) error {
+		return nil
+	})(rpcinfo.NewCtxWithRPCInfo(ctx, ri), nil, nil)
+	assert.ErrorIs(t, err, kerrors.ErrCircuitBreak)
+	assert.ErrorIs(t, err, gerrors.ErrHostEjected)
+
+	// no more than half of hosts are ejected
+	setDead(all[1], true)
+	for i := 0; i < 3; i++ {
+		assert.Error(t, submit(all[1]))
+	}
+	snapshot := cli.HealthSnapshot()
+	assert.Len(t, snapshot, 3)
+	assert.False(t, snapshot[0].Healthy)
+	assert.Equal(t, 1, snapshot[0].Ejections)
+	assert.Contains(t, snapshot[0].LastError, "connection refused")
+	assert.True(t, snapshot[1].Healthy)
+	assert.Equal(t, 3, snapshot[1].ConsecutiveFailures)
+	setDead(all[1], false)
+	assert.NoError(t, submit(all[1]))
+	assert.Equal(t, 0, cli.HealthSnapshot()[1].ConsecutiveFailures)
+
+	// brought back by probes, OnStateChange is called after the host is marked healthy
+	setDead(all[0], false)
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(changes) == 2
+	}, time.Second, 5*time.Millisecond)
+	assert.True(t, cli.HealthSnapshot()[0].Healthy)
+	mu.Lock()
+	assert.Equal(t, []string{all[0] + " false", all[0] + " true"}, changes)
+	hosts = nil
+	mu.Unlock()
+	assert.NoError(t, submit(all[0]))
+	assert.Equal(t, []string{all[0]}, hosts)
+}
+
+// sessionAtAuthClient records the auth hosts the sessions are exchanged with
+type sessionAtAuthClient struct {
+	MockedAuthClient
+	mu        sync.Mutex
+	authHosts []string
+}
+
+func (c *sessionAtAuthClient) SessionAt(hostPort string, refresh bool) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.authHosts = append(c.authHosts, hostPort)
+	return c.Session(refresh)
+}
+
+func TestHealthCheckAuth(t *testing.T) {
+	ctx := context.Background()
+	var mu sync.Mutex
+	dead := true
+	var probes []*bytegraph.GremlinQueryRequest
+	mocked := &TCtxClient{fn: func(ctx context.Context, req *bytegraph.GremlinQueryRequest) (*bytegraph.GremlinQueryResponse, error) {
+		hostPort, _ := ctx.Value(hostPortKey{}).(string)
+		if p, ok := ctx.Value(peerKey{}).(*peer); ok {
+			p.addr = hostPort
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if ctx.Value(probeKey{}) != nil {
+			probes = append(probes, req)
+		}
+		if hostPort == "10.0.0.1:9000" && dead {
+			return nil, errors.New("connection refused")
+		}
+		if req.Base == nil || !strings.Contains(req.Base.Extra[authentication.UserExtra], "session_xx") {
+			return nil, errors.New("auth failed")
+		}
+		resp := &bytegraph.GremlinQueryResponse{}
+		mockAppendResult(resp, bytegraph.ErrorCode_SUCCESS, structure.String("1"))
+		return resp, nil
+	}}
+	cli, err := NewClient(WithHostPort("10.0.0.1:9000", "10.0.0.2:9000", "10.0.0.3:9000"), WithDefaultTable("test"),
+		WithUserPwd("user", "pwd"), WithAuthPort(6288), WithHashRouting(0),
+		WithHealthCheck(HealthCheckOptions{ConsecutiveFailures: 1, ProbeInterval: 10 * time.Millisecond}))
+	assert.NoError(t, err)
+	defer cli.Close()
+	cli.setklient(mocked)
+	authCli := &sessionAtAuthClient{}
+	cli.setAuthClient(authCli)
+
+	// find a key owned by the host
+	ring := newHashRing(cli.getHostPorts(), DefaultVirtualNodes)
+	key := ""
+	for i := 0; ring.get(key) != "10.0.0.1:9000"; i++ {
+		key = fmt.Sprintf("%d:1001", i)
+	}
+	_, err = cli.Submit(context.WithValue(ctx, RouteKey{}, key), "g.V().count()")
+	assert.Equal(t, gerrors.ErrorCode_NETWORK_ERROR, gerrors.Code(err))
+	assert.False(t, cli.HealthSnapshot()[0].Healthy)
+
+	// the probes carry the session exchanged with the auth host of the probed host
+	mu.Lock()
+	dead = false
+	mu.Unlock()
+	assert.Eventually(t, func() bool {
+		return cli.HealthSnapshot()[0].Healthy
+	}, time.Second, 5*time.Millisecond)
+	mu.Lock()
+	assert.NotEmpty(t, probes)
+	assert.Contains(t, probes[len(probes)-1].Base.Extra[authentication.UserExtra], "session_xx")
+	mu.Unlock()
+	authCli.mu.Lock()
+	assert.Contains(t, authCli.authHosts, "10.0.0.1:6288")
+	authCli.mu.Unlock()
+}
+
 // mock的thrift client
-type TMockedClient struct{}
+type TMockedClient struct {
+	lastReq *bytegraph.GremlinQueryRequest
+}
 
 func (c *TMockedClient) GremlinQuery(ctx context.Context, req *bytegraph.GremlinQueryRequest, callOptions ...callopt.Option) (*bytegraph.GremlinQueryResponse, error) {
+	c.lastReq = req
 	jsonResp := `{"errCode":0,"desc":"","retPB":null,"batchRet":null,"batchDesc":[""],"batchErrCode":[0],"batchBinaryRet":["AQENAAAAAQoAAAAEbGlrZQAAAAAAAAABAAAD6gAAAAAAAAACAAAD6g=="],"txnIds":["85b5862c-1ed7-11ed-8822-acde48001122"],"txnTss":[1660814652842142],"txnId":null,"txnTs":null,"costs":[0],"BaseResp":{"StatusMessage":"","StatusCode":0,"Extra":{"IsMaster":"","idc":"boe"}}}`
 	tResp := &bytegraph.GremlinQueryResponse{}
 	json.Unmarshal([]byte(jsonResp), tResp)
@@ -54,3 +1634,104 @@ func (c *MockedAuthClient) Password() string {
 func (c *MockedAuthClient) Session(bool) (string, error) {
 	return "session_xx", nil
 }
+
+// TFuncClient 用于按请求内容构造返回值的mock thrift client
+type TFuncClient struct {
+	fn func(req *bytegraph.GremlinQueryRequest) (*bytegraph.GremlinQueryResponse, error)
+}
+
+func (c *TFuncClient) GremlinQuery(ctx context.Context, req *bytegraph.GremlinQueryRequest, callOptions ...callopt.Option) (*bytegraph.GremlinQueryResponse, error) {
+	return c.fn(req)
+}
+
+// TCtxClient 用于按context和请求内容构造返回值的mock thrift client
+type TCtxClient struct {
+	fn func(ctx context.Context, req *bytegraph.GremlinQueryRequest) (*bytegraph.GremlinQueryResponse, error)
+}
+
+func (c *TCtxClient) GremlinQuery(ctx context.Context, req *bytegraph.GremlinQueryRequest, callOptions ...callopt.Option) (*bytegraph.GremlinQueryResponse, error) {
+	return c.fn(ctx, req)
+}
+
+type mockColumn struct {
+	name   string
+	tp     columnar.ValueType
+	values []interface{} // nil is null
+}
+
+// mockAppendColumnarResult appends a successful result of one columnar table of elemType in ClientProtocol_ColumnarV1.
+func mockAppendColumnarResult(resp *bytegraph.GremlinQueryResponse, elemType structure.CoreDataType, rows int, columns []mockColumn) {
+	le := binary.LittleEndian
+	pad := func(b []byte) []byte {
+		for len(b)%8 != 0 {
+			b = append(b, 0)
+		}
+		return b
+	}
+	tb := le.AppendUint16(nil, uint16(columnar.ProtoColumnarMagicNumberUint16))
+	tb = le.AppendUint16(tb, uint16(columnar.Protocol_ColumnarV1))
+	tb = le.AppendUint32(tb, uint32(rows))
+	tb = le.AppendUint32(tb, uint32(len(columns)))
+	for _, col := range columns {
+		tb = le.AppendUint32(tb, uint32(col.tp))
+		tb = le.AppendUint32(tb, uint32(len(col.name)))
+		tb = append(tb, col.name...)
+		tb = le.AppendUint32(tb, 0)
+	}
+	tb = pad(le.AppendUint32(tb, 0))
+	for _, col := range columns {
+		nulls := make([]byte, (rows+7)/8)
+		for i, v := range col.values {
+			if v != nil {
+				nulls[i/8] |= 1 << (i % 8)
+			}
+		}
+		tb = pad(append(tb, nulls...))
+		if col.tp != columnar.ValueType_STRING {
+			for _, v := range col.values {
+				switch col.tp {
+				case columnar.ValueType_INT32:
+					v, _ := v.(int32)
+					tb = le.AppendUint32(tb, uint32(v))
+				case columnar.ValueType_INT64:
+					v, _ := v.(int64)
+					tb = le.AppendUint64(tb, uint64(v))
+				}
+			}
+			tb = pad(tb)
+			continue
+		}
+		var data []byte
+		tb = le.AppendUint32(tb, 0)
+		for _, v := range col.values {
+			v, _ := v.(string)
+			data = append(data, v...)
+			tb = le.AppendUint32(tb, uint32(len(data)))
+		}
+		tb = pad(append(pad(tb), data...))
+	}
+
+	w := &protocol.BigEndianWriter{}
+	w.WriteInt16(BinaryV1MagicNumber)
+	w.WriteInt8(int8(structure.ListType))
+	w.WriteInt32(1)
+	w.WriteInt8(int8(structure.ColumnarBinType))
+	w.WriteInt8(int8(elemType))
+	w.WriteBytes(tb)
+	resp.BatchErrCode = append(resp.BatchErrCode, bytegraph.ErrorCode_SUCCESS)
+	resp.BatchDesc = append(resp.BatchDesc, bytegraph.ErrorCode_SUCCESS.String())
+	resp.BatchBinaryRet = append(resp.BatchBinaryRet, w.Bytes())
+	resp.Costs = append(resp.Costs, 1)
+}
+
+func mockAppendResult(resp *bytegraph.GremlinQueryResponse, code bytegraph.ErrorCode, elem structure.Element) {
+	resp.BatchErrCode = append(resp.BatchErrCode, code)
+	resp.BatchDesc = append(resp.BatchDesc, code.String())
+	w := &protocol.BigEndianWriter{}
+	w.WriteInt16(BinaryV1MagicNumber)
+	if elem != nil {
+		elem.EncodeTo(w)
+	}
+	resp.BatchBinaryRet = append(resp.BatchBinaryRet, w.Bytes())
+	resp.Costs = append(resp.Costs, 1)
+}
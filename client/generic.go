@@ -0,0 +1,56 @@
+// Copyright 2022 Beijing Volcanoengine Technology Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+
+	"github.com/volcengine/vegraph-go-sdk/structure"
+)
+
+// Query submits query and converts its result to T by structure.As. The result is unwrapped by the same rules as
+// SubmitInto: a List of a single non-property element is converted as the element itself unless T is a slice, and
+// the empty and not exist results are reported by errors wrapping gerrors.ErrEmptyResult and gerrors.ErrElemNotExist.
+// For example:
+//
+//	count, err := client.Query[int64](ctx, cli, "g.V().has('id',1).has('type',1001).outE('like').count()")
+func Query[T any](ctx context.Context, cli *Client, query string, table ...string) (T, error) {
+	var ret T
+	elem, err := cli.Submit(ctx, query, table...)
+	if err != nil {
+		return ret, resultError(err)
+	}
+	if elem, err = unwrapResult(elem, &ret); err != nil {
+		return ret, err
+	}
+	return structure.As[T](elem)
+}
+
+// QueryList submits query and converts each element of its List result to T, a non-List result is converted
+// as a List of one element. An empty result is returned as an empty slice.
+func QueryList[T any](ctx context.Context, cli *Client, query string, table ...string) ([]T, error) {
+	elem, err := cli.Submit(ctx, query, table...)
+	if err != nil {
+		return nil, resultError(err)
+	}
+	switch e := elem.(type) {
+	case nil:
+		return []T{}, nil
+	case structure.List, *structure.ListStruct:
+	default:
+		elem = structure.List{e}
+	}
+	return structure.As[[]T](elem)
+}
@@ -0,0 +1,356 @@
+// Copyright 2022 Beijing Volcanoengine Technology Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/endpoint"
+	"github.com/cloudwego/kitex/pkg/kerrors"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+
+	"github.com/volcengine/vegraph-go-sdk/gerrors"
+	"github.com/volcengine/vegraph-go-sdk/kitex_gen/bytegraph"
+)
+
+const (
+	DefaultHealthConsecutiveFailures = 5
+	DefaultHealthProbeInterval       = 5 * time.Second
+	DefaultHealthProbeTimeout        = time.Second
+	DefaultHealthProbeQuery          = "g.V().limit(0)"
+	DefaultHealthHealthyProbes       = 2
+	DefaultHealthMaxEjectionPercent  = 50
+)
+
+// HealthCheckOptions controls the outlier detection of hosts, zero fields are replaced with defaults.
+// A host is ejected after ConsecutiveFailures network errors or slow responses in a row, and gets no requests
+// until it passes HealthyProbes probes in a row.
+type HealthCheckOptions struct {
+	// ConsecutiveFailures is the number of failures in a row to eject a host.
+	ConsecutiveFailures int
+	// LatencyThreshold counts a response slower than it as a failure, 0 to count network errors only.
+	LatencyThreshold time.Duration
+	// ProbeInterval is the interval to probe the ejected hosts.
+	ProbeInterval time.Duration
+	// ProbeTimeout is the timeout of a probe.
+	ProbeTimeout time.Duration
+	// ProbeQuery is the query to probe, any response within ProbeTimeout and LatencyThreshold passes the probe,
+	// even if the query fails on server. Probes are authenticated as the requests of the client.
+	ProbeQuery string
+	// ProbeTable is the table of ProbeQuery, the default table of client if empty.
+	ProbeTable string
+	// HealthyProbes is the number of passed probes in a row to bring an ejected host back.
+	HealthyProbes int
+	// MaxEjectionPercent is the max percent of hosts ejected at the same time, at least one host is kept anyway.
+	MaxEjectionPercent int
+	// OnStateChange is called when a host is ejected or brought back.
+	OnStateChange func(hostPort string, healthy bool)
+}
+
+func (o HealthCheckOptions) withDefaults() HealthCheckOptions {
+	if o.ConsecutiveFailures <= 0 {
+		o.ConsecutiveFailures = DefaultHealthConsecutiveFailures
+	}
+	if o.ProbeInterval <= 0 {
+		o.ProbeInterval = DefaultHealthProbeInterval
+	}
+	if o.ProbeTimeout <= 0 {
+		o.ProbeTimeout = DefaultHealthProbeTimeout
+	}
+	if o.ProbeQuery == "" {
+		o.ProbeQuery = DefaultHealthProbeQuery
+	}
+	if o.HealthyProbes <= 0 {
+		o.HealthyProbes = DefaultHealthHealthyProbes
+	}
+	if o.MaxEjectionPercent <= 0 || o.MaxEjectionPercent > 100 {
+		o.MaxEjectionPercent = DefaultHealthMaxEjectionPercent
+	}
+	return o
+}
+
+// HostHealth is the health snapshot of a host, see Client.HealthSnapshot.
+type HostHealth struct {
+	HostPort string
+	Healthy  bool
+	// ConsecutiveFailures is the number of failed requests in a row, or of failed probes if ejected.
+	ConsecutiveFailures int
+	// LastError is the error of the last failed request or probe.
+	LastError string
+	// LastLatency is the rpc latency of the last request or probe.
+	LastLatency time.Duration
+	// EjectedAt is the time of the last ejection, zero if never ejected.
+	EjectedAt time.Time
+	// Ejections is the number of times the host is ejected.
+	Ejections int
+}
+
+type hostHealth struct {
+	HostHealth
+	passedProbes int
+}
+
+type healthChecker struct {
+	opts HealthCheckOptions
+	c    *Client
+
+	mu    sync.Mutex
+	hosts map[string]*hostHealth
+}
+
+func newHealthChecker(opts HealthCheckOptions, c *Client) *healthChecker {
+	return &healthChecker{
+		opts:  opts.withDefaults(),
+		c:     c,
+		hosts: make(map[string]*hostHealth),
+	}
+}
+
+func (h *healthChecker) get(hostPort string) *hostHealth {
+	s := h.hosts[hostPort]
+	if s == nil {
+		s = &hostHealth{HostHealth: HostHealth{HostPort: hostPort, Healthy: true}}
+		h.hosts[hostPort] = s
+	}
+	return s
+}
+
+func (h *healthChecker) ejected(hostPort string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.hosts[hostPort]
+	return s != nil && !s.Healthy
+}
+
+// healthyHosts returns the hosts of c which are not ejected.
+func (h *healthChecker) healthyHosts() []string {
+	hostPorts := h.c.getHostPorts()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	healthy := make([]string, 0, len(hostPorts))
+	for _, hostPort := range hostPorts {
+		if s := h.hosts[hostPort]; s == nil || s.Healthy {
+			healthy = append(healthy, hostPort)
+		}
+	}
+	return healthy
+}
+
+// record counts the result of a request to hostPort, and ejects the host if it failed too many times in a row.
+func (h *healthChecker) record(hostPort string, latency time.Duration, err error) {
+	hostPorts := h.c.getHostPorts()
+	h.mu.Lock()
+	s := h.get(hostPort)
+	s.LastLatency = latency
+	if err == nil {
+		if s.Healthy {
+			s.ConsecutiveFailures = 0
+		}
+		h.mu.Unlock()
+		return
+	}
+	s.LastError = err.Error()
+	if !s.Healthy {
+		h.mu.Unlock()
+		return
+	}
+	s.ConsecutiveFailures++
+	eject := s.ConsecutiveFailures >= h.opts.ConsecutiveFailures && h.canEject(hostPorts)
+	if eject {
+		s.Healthy = false
+		s.EjectedAt = time.Now()
+		s.Ejections++
+		s.ConsecutiveFailures = 0
+		s.passedProbes = 0
+	}
+	h.mu.Unlock()
+	if eject {
+		h.c.logEvent(context.Background(), LevelWarn, "host ejected", func() []Attribute {
+			return []Attribute{{Key: "host", Value: hostPort}, {Key: "err", Value: err.Error()}}
+		})
+		if h.opts.OnStateChange != nil {
+			h.opts.OnStateChange(hostPort, false)
+		}
+	}
+}
+
+// canEject reports whether one more host of hostPorts can be ejected within MaxEjectionPercent.
+func (h *healthChecker) canEject(hostPorts []string) bool {
+	ejected := 0
+	for _, hostPort := range hostPorts {
+		if s := h.hosts[hostPort]; s != nil && !s.Healthy {
+			ejected++
+		}
+	}
+	return ejected+1 < len(hostPorts) && (ejected+1)*100 <= len(hostPorts)*h.opts.MaxEjectionPercent
+}
+
+// failure returns the error which counts as a failure of the host in call, nil if call succeeded.
+func (h *healthChecker) failure(call *Call) error {
+	if call.Response == nil && len(call.Errs) > 0 && gerrors.Code(call.Errs[0]) == gerrors.ErrorCode_NETWORK_ERROR {
+		return call.Errs[0]
+	}
+	if h.opts.LatencyThreshold > 0 && call.RPCLatency > h.opts.LatencyThreshold {
+		return errSlowResponse
+	}
+	return nil
+}
+
+var errSlowResponse = errors.New("response slower than latency threshold")
+
+func (h *healthChecker) intercept(next Endpoint) Endpoint {
+	return func(ctx context.Context, call *Call) {
+		next(ctx, call)
+		if call.Host == "" || ctx.Err() != nil || (len(call.Errs) > 0 && errors.Is(call.Errs[0], gerrors.ErrHostEjected)) {
+			// not sent, or canceled by caller, eg the losers of hedged requests
+			return
+		}
+		h.record(call.Host, call.RPCLatency, h.failure(call))
+	}
+}
+
+type probeKey struct{}
+
+// instanceMiddleware rejects the ejected host picked by load balancer. The rejection is an ErrCircuitBreak of
+// kitex, so that the load balancer picks another host.
+func (h *healthChecker) instanceMiddleware(next endpoint.Endpoint) endpoint.Endpoint {
+	return func(ctx context.Context, req, resp interface{}) error {
+		if ri := rpcinfo.GetRPCInfo(ctx); ri != nil && ri.To() != nil && ri.To().Address() != nil && ctx.Value(probeKey{}) == nil {
+			if h.ejected(ri.To().Address().String()) {
+				return kerrors.ErrCircuitBreak.WithCause(gerrors.New(gerrors.ErrorCode_NETWORK_ERROR, gerrors.ErrHostEjected))
+			}
+		}
+		return next(ctx, req, resp)
+	}
+}
+
+// probe sends ProbeQuery to hostPort with the authentication of the client, bypassing the ejection and the
+// interceptors. The session is exchanged with the auth service of hostPort if needed.
+func (h *healthChecker) probe(hostPort string) error {
+	table := h.opts.ProbeTable
+	if table == "" {
+		table = h.c.table
+	}
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), probeKey{}, true), h.opts.ProbeTimeout)
+	defer cancel()
+	if h.c.authType == AuthType_PasswordSha256 {
+		if authHostPorts, err := deriveAuthHostPorts([]string{hostPort}, h.c.authPort); err == nil {
+			ctx = context.WithValue(ctx, authHostPortKey{}, authHostPorts[0])
+		}
+	}
+	start := time.Now()
+	_, err := h.c.submit(withHostPort(ctx, hostPort), &bytegraph.GremlinQueryRequest{
+		Table:     table,
+		Queries:   []string{h.opts.ProbeQuery},
+		UseBinary: true,
+	})
+	latency := time.Since(start)
+	if err == nil && h.opts.LatencyThreshold > 0 && latency > h.opts.LatencyThreshold {
+		err = errSlowResponse
+	}
+
+	h.mu.Lock()
+	s := h.get(hostPort)
+	s.LastLatency = latency
+	if err != nil {
+		s.LastError = err.Error()
+		s.ConsecutiveFailures++
+		s.passedProbes = 0
+		h.mu.Unlock()
+		return err
+	}
+	s.passedProbes++
+	recovered := !s.Healthy && s.passedProbes >= h.opts.HealthyProbes
+	if recovered {
+		s.Healthy = true
+		s.ConsecutiveFailures = 0
+	}
+	h.mu.Unlock()
+	if recovered {
+		h.c.logEvent(context.Background(), LevelInfo, "host recovered", func() []Attribute {
+			return []Attribute{{Key: "host", Value: hostPort}}
+		})
+		if h.opts.OnStateChange != nil {
+			h.opts.OnStateChange(hostPort, true)
+		}
+	}
+	return nil
+}
+
+// probeEjected probes the ejected hosts concurrently, and forgets the hosts removed from c.
+func (h *healthChecker) probeEjected() {
+	current := make(map[string]bool)
+	for _, hostPort := range h.c.getHostPorts() {
+		current[hostPort] = true
+	}
+	var ejected []string
+	h.mu.Lock()
+	for hostPort, s := range h.hosts {
+		if !current[hostPort] {
+			delete(h.hosts, hostPort)
+		} else if !s.Healthy {
+			ejected = append(ejected, hostPort)
+		}
+	}
+	h.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, hostPort := range ejected {
+		wg.Add(1)
+		go func(hostPort string) {
+			defer wg.Done()
+			_ = h.probe(hostPort)
+		}(hostPort)
+	}
+	wg.Wait()
+}
+
+// watch probes the ejected hosts every ProbeInterval until c is closed.
+func (h *healthChecker) watch() {
+	ticker := time.NewTicker(h.opts.ProbeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-h.c.closed:
+			return
+		case <-ticker.C:
+			h.probeEjected()
+		}
+	}
+}
+
+func (h *healthChecker) snapshot() []HostHealth {
+	hostPorts := h.c.getHostPorts()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	snapshot := make([]HostHealth, 0, len(hostPorts))
+	for _, hostPort := range hostPorts {
+		snapshot = append(snapshot, h.get(hostPort).HostHealth)
+	}
+	sort.Slice(snapshot, func(i, j int) bool { return snapshot[i].HostPort < snapshot[j].HostPort })
+	return snapshot
+}
+
+// HealthSnapshot returns the health of each host sorted by host port, or nil if WithHealthCheck is not set.
+func (c *Client) HealthSnapshot() []HostHealth {
+	if c.health == nil {
+		return nil
+	}
+	return c.health.snapshot()
+}
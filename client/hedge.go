@@ -0,0 +1,258 @@
+// Copyright 2022 Beijing Volcanoengine Technology Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/kitex/client/callopt"
+
+	"github.com/volcengine/vegraph-go-sdk/kitex_gen/bytegraph"
+)
+
+const (
+	DefaultHedgePercentile  = 0.95
+	DefaultHedgeMinDelay    = time.Millisecond
+	DefaultHedgeMaxDelay    = 100 * time.Millisecond
+	DefaultHedgeMaxHedges   = 1
+	DefaultHedgeBudgetRatio = 0.1
+	DefaultHedgeBudgetBurst = 10
+
+	// hedgeSamples is the number of recent latencies the delay is computed from, it is recomputed
+	// every hedgeRecompute samples, and MaxDelay is used before hedgeMinSamples are collected.
+	hedgeSamples    = 1000
+	hedgeRecompute  = 64
+	hedgeMinSamples = 20
+)
+
+// HedgeOptions configures hedged requests, zero fields are replaced with defaults. A read-only request not
+// answered within the Percentile latency of recent reads, bounded by MinDelay and MaxDelay, is sent again to
+// another host of HostPorts, the first successful response wins and the others are canceled.
+type HedgeOptions struct {
+	Percentile float64
+	MinDelay   time.Duration
+	MaxDelay   time.Duration
+	// MaxHedges is the max number of extra requests of a request.
+	MaxHedges int
+	// BudgetRatio is the max ratio of extra requests to read requests, with bursts of up to BudgetBurst.
+	BudgetRatio float64
+	BudgetBurst int
+}
+
+func (o HedgeOptions) withDefaults() HedgeOptions {
+	if o.Percentile <= 0 || o.Percentile >= 1 {
+		o.Percentile = DefaultHedgePercentile
+	}
+	if o.MinDelay <= 0 {
+		o.MinDelay = DefaultHedgeMinDelay
+	}
+	if o.MaxDelay <= 0 {
+		o.MaxDelay = DefaultHedgeMaxDelay
+	}
+	if o.MaxDelay < o.MinDelay {
+		o.MaxDelay = o.MinDelay
+	}
+	if o.MaxHedges <= 0 {
+		o.MaxHedges = DefaultHedgeMaxHedges
+	}
+	if o.BudgetRatio <= 0 {
+		o.BudgetRatio = DefaultHedgeBudgetRatio
+	}
+	if o.BudgetBurst <= 0 {
+		o.BudgetBurst = DefaultHedgeBudgetBurst
+	}
+	return o
+}
+
+type hostPortKey struct{}
+
+// withHostPort pins the requests of ctx to hostPort instead of the one picked by load balancer.
+func withHostPort(ctx context.Context, hostPort string) context.Context {
+	return context.WithValue(ctx, hostPortKey{}, hostPort)
+}
+
+func (c *Client) gremlinQuery(ctx context.Context, request *bytegraph.GremlinQueryRequest) (*bytegraph.GremlinQueryResponse, error) {
+	if hostPort, ok := ctx.Value(hostPortKey{}).(string); ok {
+		return c.getKlient().GremlinQuery(ctx, request, callopt.WithHostPort(hostPort))
+	}
+	return c.getKlient().GremlinQuery(ctx, request)
+}
+
+type hedger struct {
+	opts  HedgeOptions
+	hosts func() []string
+
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+	added   int
+	delay   time.Duration
+	tokens  float64
+}
+
+func newHedger(opts HedgeOptions, hosts func() []string) *hedger {
+	opts = opts.withDefaults()
+	return &hedger{
+		opts:   opts,
+		hosts:  hosts,
+		delay:  opts.MaxDelay,
+		tokens: float64(opts.BudgetBurst),
+	}
+}
+
+// observe records the latency of a successful read, and recomputes the delay periodically.
+func (h *hedger) observe(latency time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.samples) < hedgeSamples {
+		h.samples = append(h.samples, latency)
+	} else {
+		h.samples[h.next] = latency
+		h.next = (h.next + 1) % hedgeSamples
+	}
+	h.added++
+	if len(h.samples) < hedgeMinSamples || (h.added%hedgeRecompute != 0 && len(h.samples) != hedgeMinSamples) {
+		return
+	}
+	sorted := append([]time.Duration(nil), h.samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	delay := sorted[int(math.Ceil(h.opts.Percentile*float64(len(sorted))))-1]
+	if delay < h.opts.MinDelay {
+		delay = h.opts.MinDelay
+	}
+	if delay > h.opts.MaxDelay {
+		delay = h.opts.MaxDelay
+	}
+	h.delay = delay
+}
+
+func (h *hedger) currentDelay() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.delay
+}
+
+// earn adds the budget of a read request.
+func (h *hedger) earn() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.tokens = math.Min(float64(h.opts.BudgetBurst), h.tokens+h.opts.BudgetRatio)
+}
+
+// spend takes the budget of an extra request.
+func (h *hedger) spend() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.tokens < 1 {
+		return false
+	}
+	h.tokens--
+	return true
+}
+
+// pickHosts returns up to 1+MaxHedges distinct hosts in random order, starting with the host pinned in ctx
+// by WithHashRouting if any.
+func (h *hedger) pickHosts(ctx context.Context) []string {
+	hosts := append([]string(nil), h.hosts()...)
+	rand.Shuffle(len(hosts), func(i, j int) { hosts[i], hosts[j] = hosts[j], hosts[i] })
+	if pinned, ok := ctx.Value(hostPortKey{}).(string); ok {
+		for i, host := range hosts {
+			if host == pinned {
+				hosts[0], hosts[i] = hosts[i], hosts[0]
+				break
+			}
+		}
+	}
+	if len(hosts) > 1+h.opts.MaxHedges {
+		hosts = hosts[:1+h.opts.MaxHedges]
+	}
+	return hosts
+}
+
+func (h *hedger) intercept(next Endpoint) Endpoint {
+	return func(ctx context.Context, call *Call) {
+		if isWriteRequest(call.Request) {
+			next(ctx, call)
+			return
+		}
+		hosts := h.pickHosts(ctx)
+		if len(hosts) < 2 {
+			next(ctx, call)
+			return
+		}
+		h.earn()
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		// each attempt sends a copy of call, since the losers may still be running after the winner returns
+		done := make(chan *Call, len(hosts))
+		launched := 0
+		launch := func() {
+			attempt := &Call{Table: call.Table, Request: cloneRequest(call.Request), AuthType: call.AuthType}
+			go func(hostPort string) {
+				next(withHostPort(ctx, hostPort), attempt)
+				done <- attempt
+			}(hosts[launched])
+			launched++
+		}
+		launch()
+
+		timer := time.NewTimer(h.currentDelay())
+		defer timer.Stop()
+		var last *Call
+		for pending := 1; pending > 0; {
+			select {
+			case last = <-done:
+				pending--
+				if last.Response != nil {
+					h.observe(last.RPCLatency)
+					pending = 0
+				} else if launched < len(hosts) && h.spend() {
+					// hedge at once if an attempt failed before getting a response
+					launch()
+					pending++
+				}
+			case <-timer.C:
+				if launched < len(hosts) && h.spend() {
+					launch()
+					pending++
+					timer.Reset(h.currentDelay())
+				}
+			}
+		}
+		request := call.Request
+		*call = *last
+		call.Request = request
+	}
+}
+
+// cloneRequest copies request with its Base, which is modified when sending.
+func cloneRequest(request *bytegraph.GremlinQueryRequest) *bytegraph.GremlinQueryRequest {
+	clone := *request
+	if request.Base != nil {
+		b := *request.Base
+		b.Extra = make(map[string]string, len(request.Base.Extra))
+		for k, v := range request.Base.Extra {
+			b.Extra[k] = v
+		}
+		clone.Base = &b
+	}
+	return &clone
+}
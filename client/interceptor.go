@@ -0,0 +1,75 @@
+// Copyright 2022 Beijing Volcanoengine Technology Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/volcengine/vegraph-go-sdk/kitex_gen/bytegraph"
+	"github.com/volcengine/vegraph-go-sdk/structure"
+)
+
+// Call is a batch request to server as seen by interceptors. Request can be modified before calling next,
+// eg: to tag it in Request.Base.Extra. The other fields are filled by the innermost Endpoint.
+type Call struct {
+	Table    string
+	Request  *bytegraph.GremlinQueryRequest
+	AuthType AuthType
+
+	// Host is the address of server the request was sent to, it is empty if unknown.
+	Host string
+	// Response is nil if the request failed before getting a response.
+	Response *bytegraph.GremlinQueryResponse
+	// Results, Extras and Errs are indexed by the queries of request, Errs must be set for each query.
+	Results []structure.Element
+	Extras  []*structure.Extra
+	Errs    []error
+	// RPCLatency includes authentication and the rpc, DecodeLatency is the time of decoding results.
+	RPCLatency    time.Duration
+	DecodeLatency time.Duration
+}
+
+// Queries returns the queries or templates of the request.
+func (call *Call) Queries() []string {
+	if len(call.Request.Templates) > 0 {
+		return call.Request.Templates
+	}
+	return call.Request.Queries
+}
+
+// Endpoint handles a Call by filling its results.
+type Endpoint func(ctx context.Context, call *Call)
+
+// Interceptor wraps an Endpoint, in the same way as the middleware of kitex. It is called for each request sent
+// to server, including retries and the batches of BatchSubmitChunked and WithBatching. For example, to inject faults:
+//
+//	func FaultInjector(next client.Endpoint) client.Endpoint {
+//		return func(ctx context.Context, call *client.Call) {
+//			if rand.Intn(100) == 0 {
+//				call.Errs = gerrors.DuplicateErr(gerrors.New(gerrors.ErrorCode_RETRY), len(call.Queries()))
+//				return
+//			}
+//			next(ctx, call)
+//		}
+//	}
+type Interceptor func(next Endpoint) Endpoint
+
+func chainInterceptors(interceptors []Interceptor, endpoint Endpoint) Endpoint {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		endpoint = interceptors[i](endpoint)
+	}
+	return endpoint
+}
@@ -0,0 +1,124 @@
+// Copyright 2022 Beijing Volcanoengine Technology Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/volcengine/vegraph-go-sdk/gerrors"
+	"github.com/volcengine/vegraph-go-sdk/structure"
+)
+
+// SubmitInto submits query and binds its result to dest, which must be a pointer.
+// A List result of a single non-property element is bound as the element itself, so `count()` can be bound to *int64.
+// It returns an error wrapping gerrors.ErrEmptyResult if result is empty, or wrapping gerrors.ErrElemNotExist
+// if server returns ErrorCode_ELEM_NOT_EXIST, ErrorCode_POINT_NOT_EXIST or ErrorCode_EDGE_NOT_EXIST.
+func (c *Client) SubmitInto(ctx context.Context, query string, dest interface{}, table ...string) error {
+	elem, err := c.Submit(ctx, query, table...)
+	return bindResult(elem, err, dest)
+}
+
+// BatchSubmitInto works like SubmitInto, the result of queries[i] is bound to dests[i], and reported by errs[i].
+func (c *Client) BatchSubmitInto(ctx context.Context, queries []string, dests []interface{}, table ...string) []error {
+	if len(queries) != len(dests) {
+		return []error{gerrors.New(gerrors.ErrorCode_INVALID_REQUEST, fmt.Errorf("number of queries(%d) and dests(%d) not equal", len(queries), len(dests)))}
+	}
+	results := c.BatchSubmitEx(ctx, queries, table...)
+	errs := make([]error, len(results))
+	for i, result := range results {
+		if i >= len(dests) {
+			errs[i] = result.Err
+			continue
+		}
+		errs[i] = bindResult(result.Element, result.Err, dests[i])
+	}
+	return errs
+}
+
+// resultError wraps the not exist errors of server with gerrors.ErrElemNotExist.
+func resultError(err error) error {
+	switch gerrors.Code(err) {
+	case gerrors.ErrorCode_ELEM_NOT_EXIST, gerrors.ErrorCode_POINT_NOT_EXIST, gerrors.ErrorCode_EDGE_NOT_EXIST:
+		return gerrors.New(gerrors.Code(err), fmt.Errorf("%w: %v", gerrors.ErrElemNotExist, err))
+	}
+	return err
+}
+
+func bindResult(elem structure.Element, err error, dest interface{}) error {
+	if err != nil {
+		return resultError(err)
+	}
+	if dest == nil || reflect.TypeOf(dest).Kind() != reflect.Ptr {
+		return fmt.Errorf("%w, dest must be a non-nil pointer, got %T", gerrors.ErrOrmTypeMismatch, dest)
+	}
+	elem, err = unwrapResult(elem, dest)
+	if err != nil {
+		return err
+	}
+	switch e := elem.(type) {
+	case structure.List, structure.Map, *structure.Vertex, *structure.Edge, *structure.Property, structure.Bool,
+		structure.Int32, structure.Int64, structure.Float32, structure.Float64, structure.String:
+		return e.BindTo(dest)
+	default:
+		return fmt.Errorf("%w, %T", gerrors.ErrOrmUnsupportedElemType, elem)
+	}
+}
+
+// unwrapResult applies the result rules shared by SubmitInto and Query to elem before it is bound to dest, a
+// pointer: a ListStruct is taken as List, an empty result is ErrEmptyResult, and a List of a single non-property
+// element is unwrapped unless dest is a slice.
+func unwrapResult(elem structure.Element, dest interface{}) (structure.Element, error) {
+	if ls, ok := elem.(*structure.ListStruct); ok {
+		elem = structure.List(ls.Elems)
+	}
+	switch e := elem.(type) {
+	case nil:
+		return nil, gerrors.ErrEmptyResult
+	case structure.List:
+		if len(e) == 0 {
+			return nil, gerrors.ErrEmptyResult
+		}
+		if !isSliceDest(dest) && !isPropertyList(e) {
+			if len(e) > 1 {
+				return nil, fmt.Errorf("%w, cannot bind List of %d elements to %T, dest must be slice", gerrors.ErrOrmTypeMismatch, len(e), dest)
+			}
+			return unwrapResult(e[0], dest)
+		}
+	case structure.Map:
+		if len(e) == 0 {
+			return nil, gerrors.ErrEmptyResult
+		}
+	}
+	return elem, nil
+}
+
+func isSliceDest(dest interface{}) bool {
+	t := reflect.TypeOf(dest).Elem()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Slice
+}
+
+func isPropertyList(l structure.List) bool {
+	for _, e := range l {
+		if _, ok := e.(*structure.Property); !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,206 @@
+// Copyright 2022 Beijing Volcanoengine Technology Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/volcengine/vegraph-go-sdk/gerrors"
+)
+
+type LimitMode int
+
+const (
+	// LimitModeBlock waits until the request is allowed, or fails it if the context is done before that.
+	LimitModeBlock LimitMode = iota
+	// LimitModeFailFast fails the request with ErrorCode_CLIENT_LIMITED at once if it is not allowed.
+	LimitModeFailFast
+)
+
+// Limit is the client side limit of requests sent to server, zero fields mean no limit.
+type Limit struct {
+	// QPS is the rate of queries, a request takes one token per query from a bucket of Burst tokens.
+	QPS float64
+	// Burst defaults to QPS rounded up, a request of more queries than Burst waits for a full bucket.
+	Burst int
+	// MaxInFlight is the max number of requests being sent at the same time.
+	MaxInFlight int
+	Mode        LimitMode
+}
+
+type limiter struct {
+	mode     LimitMode
+	bucket   *tokenBucket
+	inFlight chan struct{}
+}
+
+func newLimiter(limit Limit) *limiter {
+	l := &limiter{mode: limit.Mode}
+	if limit.QPS > 0 {
+		burst := limit.Burst
+		if burst <= 0 {
+			burst = int(math.Ceil(limit.QPS))
+		}
+		l.bucket = newTokenBucket(limit.QPS, burst)
+	}
+	if limit.MaxInFlight > 0 {
+		l.inFlight = make(chan struct{}, limit.MaxInFlight)
+	}
+	return l
+}
+
+// acquire takes an in-flight slot, release must be called after the request.
+func (l *limiter) acquire(ctx context.Context) (release func(), err error) {
+	if l.inFlight == nil {
+		return func() {}, nil
+	}
+	if l.mode == LimitModeFailFast {
+		select {
+		case l.inFlight <- struct{}{}:
+		default:
+			return nil, gerrors.New(gerrors.ErrorCode_CLIENT_LIMITED, gerrors.ErrConcurrencyLimited)
+		}
+	} else {
+		select {
+		case l.inFlight <- struct{}{}:
+		case <-ctx.Done():
+			return nil, gerrors.New(gerrors.ErrorCode_NETWORK_ERROR, ctx.Err())
+		}
+	}
+	return func() { <-l.inFlight }, nil
+}
+
+// take waits for the tokens of n queries, refund gives them back if the request is not sent at last.
+func (l *limiter) take(ctx context.Context, n int) (refund func(), err error) {
+	if l.bucket == nil {
+		return func() {}, nil
+	}
+	if err = l.bucket.wait(ctx, n, l.mode == LimitModeFailFast); err != nil {
+		return nil, err
+	}
+	return func() { l.bucket.refund(n) }, nil
+}
+
+type tokenBucket struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// wait takes n tokens, capped to burst. The tokens may be borrowed from the future, in which case it waits
+// until they are refilled, unless failFast is set or the context would be done before that.
+func (b *tokenBucket) wait(ctx context.Context, n int, failFast bool) error {
+	tokens := math.Min(float64(n), b.burst)
+	now := time.Now()
+
+	b.mu.Lock()
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	var delay time.Duration
+	if lack := tokens - b.tokens; lack > 0 {
+		delay = time.Duration(lack / b.rate * float64(time.Second))
+	}
+	deadline, hasDeadline := ctx.Deadline()
+	if delay > 0 && (failFast || hasDeadline && now.Add(delay).After(deadline)) {
+		b.mu.Unlock()
+		return gerrors.New(gerrors.ErrorCode_CLIENT_LIMITED, gerrors.ErrRateLimited)
+	}
+	b.tokens -= tokens
+	b.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+	if err := sleepContext(ctx, delay); err != nil {
+		b.refund(n)
+		return gerrors.New(gerrors.ErrorCode_NETWORK_ERROR, err)
+	}
+	return nil
+}
+
+// refund gives back the tokens of n queries taken by wait.
+func (b *tokenBucket) refund(n int) {
+	b.mu.Lock()
+	b.tokens = math.Min(b.burst, b.tokens+math.Min(float64(n), b.burst))
+	b.mu.Unlock()
+}
+
+// limiters applies the limit of table and then the global limit to each request. The in-flight slots are
+// acquired before the tokens, so a request waiting for a slot does not hold tokens, and the tokens already
+// taken are refunded if a later limit rejects the request.
+type limiters struct {
+	global *limiter
+	tables map[string]*limiter
+}
+
+func newLimiters(global *Limit, tables map[string]Limit) *limiters {
+	ls := &limiters{tables: make(map[string]*limiter, len(tables))}
+	if global != nil {
+		ls.global = newLimiter(*global)
+	}
+	for table, limit := range tables {
+		ls.tables[table] = newLimiter(limit)
+	}
+	return ls
+}
+
+func (ls *limiters) intercept(next Endpoint) Endpoint {
+	return func(ctx context.Context, call *Call) {
+		n := len(call.Queries())
+		var limits []*limiter
+		for _, l := range []*limiter{ls.tables[call.Table], ls.global} {
+			if l != nil {
+				limits = append(limits, l)
+			}
+		}
+		var releases []func()
+		defer func() {
+			for _, release := range releases {
+				release()
+			}
+		}()
+		for _, l := range limits {
+			release, err := l.acquire(ctx)
+			if err != nil {
+				call.Errs = gerrors.DuplicateErr(err, n)
+				return
+			}
+			releases = append(releases, release)
+		}
+		var refunds []func()
+		for _, l := range limits {
+			refund, err := l.take(ctx, n)
+			if err != nil {
+				for _, refund := range refunds {
+					refund()
+				}
+				call.Errs = gerrors.DuplicateErr(err, n)
+				return
+			}
+			refunds = append(refunds, refund)
+		}
+		next(ctx, call)
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright 2022 Beijing Volcanoengine Technology Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Level is the severity of log events, with the same values as the levels of log/slog.
+type Level int
+
+const (
+	LevelDebug Level = -4
+	LevelInfo  Level = 0
+	LevelWarn  Level = 4
+	LevelError Level = 8
+)
+
+// levelOff is above every level, so the default Logger only logs the debug events of requests with DebugKey{}.
+const levelOff Level = math.MaxInt32
+
+func (l Level) String() string {
+	switch {
+	case l < LevelInfo:
+		return "DEBUG"
+	case l < LevelWarn:
+		return "INFO"
+	case l < LevelError:
+		return "WARN"
+	default:
+		return "ERROR"
+	}
+}
+
+// Logger receives the log events of client, see WithLogger. Credentials in the request are redacted
+// and binary payloads are truncated before logging.
+type Logger interface {
+	// Enabled reports whether events of level should be logged, to skip building them.
+	Enabled(ctx context.Context, level Level) bool
+	Log(ctx context.Context, level Level, msg string, attrs ...Attribute)
+}
+
+// newDefaultLogger returns the Logger used without WithLogger, it keeps silent except for requests with
+// DebugKey{}, whose debug events are written to stdout as the former debug middleware did.
+func newDefaultLogger() Logger {
+	return NewTextLogger(os.Stdout, levelOff)
+}
+
+// NewTextLogger returns a Logger writing events of level and above to w, one line per event in the
+// key=value format of slog.TextHandler. Debug events of requests whose context carries DebugKey{} are
+// logged regardless of level.
+func NewTextLogger(w io.Writer, level Level) Logger {
+	return &textLogger{w: w, level: level}
+}
+
+type textLogger struct {
+	mu    sync.Mutex
+	w     io.Writer
+	level Level
+}
+
+func (l *textLogger) Enabled(ctx context.Context, level Level) bool {
+	if level >= l.level {
+		return true
+	}
+	debug, _ := ctx.Value(DebugKey{}).(bool)
+	return debug
+}
+
+func (l *textLogger) Log(ctx context.Context, level Level, msg string, attrs ...Attribute) {
+	var b strings.Builder
+	b.WriteString("time=")
+	b.WriteString(time.Now().Format(time.RFC3339Nano))
+	b.WriteString(" level=")
+	b.WriteString(level.String())
+	b.WriteString(" msg=")
+	b.WriteString(quoteIfNeeded(msg))
+	for _, attr := range attrs {
+		b.WriteString(" ")
+		b.WriteString(attr.Key)
+		b.WriteString("=")
+		b.WriteString(quoteIfNeeded(formatLogValue(attr.Value)))
+	}
+	b.WriteString("\n")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = io.WriteString(l.w, b.String())
+}
+
+func formatLogValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case error:
+		return v.Error()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func quoteIfNeeded(s string) string {
+	if s == "" {
+		return `""`
+	}
+	for _, r := range s {
+		if r == '=' || r == '"' || unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
+
+// SlogLogger is the method set of *slog.Logger used by NewSlogLogger.
+type SlogLogger[L ~int] interface {
+	Enabled(ctx context.Context, level L) bool
+	Log(ctx context.Context, level L, msg string, args ...interface{})
+}
+
+// NewSlogLogger adapts a *slog.Logger of log/slog or golang.org/x/exp/slog as Logger, eg:
+//
+//	client.WithLogger(client.NewSlogLogger[slog.Level](slog.Default()))
+func NewSlogLogger[L ~int](logger SlogLogger[L]) Logger {
+	return &slogLogger[L]{logger: logger}
+}
+
+type slogLogger[L ~int] struct {
+	logger SlogLogger[L]
+}
+
+func (l *slogLogger[L]) Enabled(ctx context.Context, level Level) bool {
+	return l.logger.Enabled(ctx, L(level))
+}
+
+func (l *slogLogger[L]) Log(ctx context.Context, level Level, msg string, attrs ...Attribute) {
+	args := make([]interface{}, 0, 2*len(attrs))
+	for _, attr := range attrs {
+		args = append(args, attr.Key, attr.Value)
+	}
+	l.logger.Log(ctx, L(level), msg, args...)
+}
+
+// logEvent logs an event if its level is enabled, attrs are only built in that case.
+func (c *Client) logEvent(ctx context.Context, level Level, msg string, attrs func() []Attribute) {
+	if !c.logger.Enabled(ctx, level) {
+		return
+	}
+	c.logger.Log(ctx, level, msg, attrs()...)
+}
@@ -0,0 +1,100 @@
+// Copyright 2022 Beijing Volcanoengine Technology Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+
+	"github.com/cloudwego/kitex/pkg/endpoint"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+
+	"github.com/volcengine/vegraph-go-sdk/client/metrics"
+	"github.com/volcengine/vegraph-go-sdk/gerrors"
+)
+
+const (
+	MetricRPCDuration = "bytegraph_client_rpc_duration_seconds"
+	MetricQueryCost   = "bytegraph_client_query_cost"
+	MetricBatchSize   = "bytegraph_client_batch_size"
+	MetricQueries     = "bytegraph_client_queries_total"
+)
+
+// CostBuckets are for the costs returned by server.
+var CostBuckets = metrics.ExponentialBuckets(1, 4, 12)
+
+type clientMetrics struct {
+	rpcDuration metrics.Histogram
+	cost        metrics.Histogram
+	batchSize   metrics.Histogram
+	queries     metrics.Counter
+}
+
+func newClientMetrics(registry metrics.Registry) *clientMetrics {
+	return &clientMetrics{
+		rpcDuration: registry.Histogram(MetricRPCDuration, "Latency of requests to server, including authentication.",
+			metrics.LatencyBuckets, "table", "host"),
+		cost:      registry.Histogram(MetricQueryCost, "Cost of queries returned by server.", CostBuckets, "table", "host"),
+		batchSize: registry.Histogram(MetricBatchSize, "Number of queries in requests.", metrics.SizeBuckets, "table", "host"),
+		queries:   registry.Counter(MetricQueries, "Number of queries by error code.", "table", "host", "code"),
+	}
+}
+
+// intercept records the metrics of each call, it is the outermost interceptor so injected faults are counted too.
+func (m *clientMetrics) intercept(next Endpoint) Endpoint {
+	return func(ctx context.Context, call *Call) {
+		next(ctx, call)
+		table, host := call.Table, call.Host
+		if call.RPCLatency > 0 {
+			m.rpcDuration.Observe(call.RPCLatency.Seconds(), table, host)
+		}
+		m.batchSize.Observe(float64(len(call.Queries())), table, host)
+		for _, extra := range call.Extras {
+			if extra != nil {
+				m.cost.Observe(float64(extra.Cost), table, host)
+			}
+		}
+		codes := make(map[gerrors.ErrorCode]int)
+		for _, err := range call.Errs {
+			codes[gerrors.Code(err)]++
+		}
+		for code, n := range codes {
+			m.queries.Add(float64(n), table, host, code.String())
+		}
+	}
+}
+
+type peerKey struct{}
+
+// peer holds the address of server a request is sent to, which is filled by peerMiddleware.
+type peer struct {
+	addr string
+}
+
+func withPeer(ctx context.Context) (context.Context, *peer) {
+	p := &peer{}
+	return context.WithValue(ctx, peerKey{}, p), p
+}
+
+func peerMiddleware(next endpoint.Endpoint) endpoint.Endpoint {
+	return func(ctx context.Context, req, resp interface{}) error {
+		err := next(ctx, req, resp)
+		if p, ok := ctx.Value(peerKey{}).(*peer); ok {
+			if ri := rpcinfo.GetRPCInfo(ctx); ri != nil && ri.To() != nil && ri.To().Address() != nil {
+				p.addr = ri.To().Address().String()
+			}
+		}
+		return err
+	}
+}
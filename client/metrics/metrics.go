@@ -0,0 +1,51 @@
+// Copyright 2022 Beijing Volcanoengine Technology Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics defines the registry used by client to record its metrics, and a Prometheus compatible
+// implementation of it which needs no dependency.
+package metrics
+
+// Registry creates metrics. Creating a metric of the same name again returns the existing one, so a registry
+// can be shared by clients.
+type Registry interface {
+	Counter(name, help string, labelNames ...string) Counter
+	Histogram(name, help string, buckets []float64, labelNames ...string) Histogram
+}
+
+// Counter is a monotonic counter, labelValues are in the order of labelNames it was created with.
+type Counter interface {
+	Add(delta float64, labelValues ...string)
+}
+
+// Histogram counts observations in buckets, labelValues are in the order of labelNames it was created with.
+type Histogram interface {
+	Observe(value float64, labelValues ...string)
+}
+
+var (
+	// LatencyBuckets are in seconds, from 1ms to about 8s.
+	LatencyBuckets = ExponentialBuckets(0.001, 2, 14)
+	// SizeBuckets are for batch sizes, from 1 to 4096.
+	SizeBuckets = ExponentialBuckets(1, 2, 13)
+)
+
+// ExponentialBuckets returns count buckets, starting from start and multiplied by factor.
+func ExponentialBuckets(start, factor float64, count int) []float64 {
+	buckets := make([]float64, count)
+	for i := range buckets {
+		buckets[i] = start
+		start *= factor
+	}
+	return buckets
+}
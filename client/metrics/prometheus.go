@@ -0,0 +1,261 @@
+// Copyright 2022 Beijing Volcanoengine Technology Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	typeCounter   = "counter"
+	typeHistogram = "histogram"
+
+	// labelSep joins label values into the key of series, it is not valid in UTF-8 label values.
+	labelSep = "\xff"
+)
+
+// PrometheusRegistry is a Registry which exposes its metrics in the Prometheus text format, eg:
+//
+//	registry := metrics.NewPrometheusRegistry()
+//	cli, err := client.NewClient(client.WithMetrics(registry), ...)
+//	http.Handle("/metrics", registry)
+type PrometheusRegistry struct {
+	mu       sync.Mutex
+	families []*family
+	byName   map[string]*family
+}
+
+func NewPrometheusRegistry() *PrometheusRegistry {
+	return &PrometheusRegistry{byName: make(map[string]*family)}
+}
+
+type family struct {
+	name       string
+	help       string
+	typ        string
+	labelNames []string
+	buckets    []float64
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+	// counts are per bucket and not cumulative, the last one is for +Inf.
+	counts []uint64
+	sum    float64
+}
+
+func (r *PrometheusRegistry) family(name, help, typ string, buckets []float64, labelNames []string) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if f, ok := r.byName[name]; ok {
+		if f.typ != typ {
+			panic(fmt.Sprintf("metric %s is already registered as %s", name, f.typ))
+		}
+		return f
+	}
+	f := &family{
+		name:       name,
+		help:       help,
+		typ:        typ,
+		labelNames: labelNames,
+		buckets:    buckets,
+		series:     make(map[string]*series),
+	}
+	r.families = append(r.families, f)
+	r.byName[name] = f
+	return f
+}
+
+func (r *PrometheusRegistry) Counter(name, help string, labelNames ...string) Counter {
+	return (*counter)(r.family(name, help, typeCounter, nil, labelNames))
+}
+
+func (r *PrometheusRegistry) Histogram(name, help string, buckets []float64, labelNames ...string) Histogram {
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return (*histogram)(r.family(name, help, typeHistogram, buckets, labelNames))
+}
+
+// get returns the series of labelValues, f.mu must be held.
+func (f *family) get(labelValues []string) *series {
+	key := strings.Join(labelValues, labelSep)
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labelValues: append([]string(nil), labelValues...)}
+		if f.typ == typeHistogram {
+			s.counts = make([]uint64, len(f.buckets)+1)
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+type counter family
+
+func (c *counter) Add(delta float64, labelValues ...string) {
+	f := (*family)(c)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.get(labelValues).value += delta
+}
+
+type histogram family
+
+func (h *histogram) Observe(value float64, labelValues ...string) {
+	f := (*family)(h)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s := f.get(labelValues)
+	s.counts[sort.SearchFloat64s(f.buckets, value)]++
+	s.sum += value
+}
+
+// WriteTo writes all metrics in the Prometheus text format.
+func (r *PrometheusRegistry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	families := append([]*family(nil), r.families...)
+	r.mu.Unlock()
+
+	cw := &countWriter{w: bufio.NewWriter(w)}
+	for _, f := range families {
+		f.writeTo(cw)
+	}
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+	return cw.n, cw.err
+}
+
+// ServeHTTP serves the metrics for Prometheus to scrape.
+func (r *PrometheusRegistry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = r.WriteTo(w)
+}
+
+func (f *family) writeTo(w *countWriter) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.series) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	w.printf("# HELP %s %s\n", f.name, escapeHelp(f.help))
+	w.printf("# TYPE %s %s\n", f.name, f.typ)
+	for _, key := range keys {
+		s := f.series[key]
+		if f.typ == typeCounter {
+			w.printf("%s%s %s\n", f.name, f.labels(s, ""), formatFloat(s.value))
+			continue
+		}
+		var cumulative uint64
+		for i, count := range s.counts {
+			cumulative += count
+			le := "+Inf"
+			if i < len(f.buckets) {
+				le = formatFloat(f.buckets[i])
+			}
+			w.printf("%s_bucket%s %d\n", f.name, f.labels(s, le), cumulative)
+		}
+		w.printf("%s_sum%s %s\n", f.name, f.labels(s, ""), formatFloat(s.sum))
+		w.printf("%s_count%s %d\n", f.name, f.labels(s, ""), cumulative)
+	}
+}
+
+// labels renders the labels of s, with the le label of histogram bucket if it is not empty.
+func (f *family) labels(s *series, le string) string {
+	var b strings.Builder
+	for i, name := range f.labelNames {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		value := ""
+		if i < len(s.labelValues) {
+			value = s.labelValues[i]
+		}
+		b.WriteString(name)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabel(value))
+		b.WriteString(`"`)
+	}
+	if le != "" {
+		if b.Len() > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString(`le="`)
+		b.WriteString(le)
+		b.WriteString(`"`)
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return "{" + b.String() + "}"
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+type countWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (w *countWriter) printf(format string, args ...interface{}) {
+	if w.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(w.w, format, args...)
+	w.n += int64(n)
+	w.err = err
+}
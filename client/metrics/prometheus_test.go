@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrometheusRegistry(t *testing.T) {
+	r := NewPrometheusRegistry()
+	requests := r.Counter("requests_total", "Requests.", "table", "code")
+	requests.Add(1, "t1", "SUCCESS")
+	requests.Add(2, "t1", "SUCCESS")
+	requests.Add(1, `t"2`, "NETWORK_ERROR")
+	assert.Equal(t, requests, r.Counter("requests_total", "Requests.", "table", "code"))
+
+	latency := r.Histogram("latency_seconds", "Latency\nin seconds.", []float64{0.1, 0.01, 1}, "table")
+	latency.Observe(0.005, "t1")
+	latency.Observe(0.05, "t1")
+	latency.Observe(5, "t1")
+	r.Histogram("empty", "Not observed.", SizeBuckets)
+
+	var b strings.Builder
+	n, err := r.WriteTo(&b)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(b.Len()), n)
+	assert.Equal(t, `# HELP requests_total Requests.
+# TYPE requests_total counter
+requests_total{table="t\"2",code="NETWORK_ERROR"} 1
+requests_total{table="t1",code="SUCCESS"} 3
+# HELP latency_seconds Latency\nin seconds.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{table="t1",le="0.01"} 1
+latency_seconds_bucket{table="t1",le="0.1"} 2
+latency_seconds_bucket{table="t1",le="1"} 2
+latency_seconds_bucket{table="t1",le="+Inf"} 3
+latency_seconds_sum{table="t1"} 5.055
+latency_seconds_count{table="t1"} 3
+`, b.String())
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, b.String(), rec.Body.String())
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain")
+
+	assert.Panics(t, func() { r.Histogram("requests_total", "", nil) })
+}
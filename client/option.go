@@ -18,6 +18,9 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"time"
+
+	"github.com/volcengine/vegraph-go-sdk/client/metrics"
+	"github.com/volcengine/vegraph-go-sdk/kitex_gen/bytegraph"
 )
 
 type Option func(*Options)
@@ -30,9 +33,11 @@ type Options struct {
 	passwordSha256 string
 	authPort       int
 
-	HostPorts  []string
-	domainName string
-	port       int
+	HostPorts       []string
+	domainName      string
+	port            int
+	resolver        Resolver
+	resolveInterval time.Duration
 
 	MaxIdle         int
 	MaxIdleGlobal   int
@@ -41,6 +46,30 @@ type Options struct {
 	DecodeUseStruct bool
 	// compression 是否开启返回值压缩，用于大数据量下降低带宽。需要集群支持,业务侧无感知。开了可能会导致cpu上升。
 	compression bool
+	// expectProtocol 期望服务端返回的结果协议，服务端不支持时仍按binary协议返回，客户端均可解析。
+	expectProtocol ClientProtocol
+	retryPolicy    *RetryPolicy
+	tracer         Tracer
+	metrics        metrics.Registry
+
+	logger          Logger
+	logPayloadLimit int
+	slowQuery       *SlowQueryOptions
+
+	limit         *Limit
+	tableLimits   map[string]Limit
+	breaker       *BreakerOptions
+	adaptiveLimit *AdaptiveLimitOptions
+	hedge         *HedgeOptions
+	healthCheck   *HealthCheckOptions
+	hashRouting   bool
+	virtualNodes  int
+
+	batching     bool
+	batchWindow  time.Duration
+	maxBatchSize int
+
+	interceptors []Interceptor
 }
 
 type AuthType int
@@ -51,6 +80,13 @@ const (
 	AuthType_PasswordEncrypt
 )
 
+type ClientProtocol = bytegraph.ClientProtocol
+
+const (
+	ClientProtocol_Binary     = bytegraph.ClientProtocol_Binary
+	ClientProtocol_ColumnarV1 = bytegraph.ClientProtocol_ColumnarV1
+)
+
 func newDefaultOptions() *Options {
 	return &Options{
 		authPort:       6287,
@@ -59,6 +95,9 @@ func newDefaultOptions() *Options {
 		MaxIdleTimeout: DefaultMaxIdleTimeout,
 		RpcTimeout:     DefaultRpcTimeout,
 		MaxIdleGlobal:  DefaultMaxIdleGlobal,
+
+		logger:          newDefaultLogger(),
+		logPayloadLimit: DefaultLogPayloadLimit,
 	}
 }
 
@@ -101,6 +140,8 @@ func WithAuthPort(port int) Option {
 	}
 }
 
+// WithServiceNamePort discovers the hosts by the A and AAAA records of domainName, see NewDNSResolver.
+// It's ignored if WithResolver is set.
 func WithServiceNamePort(domainName string, port int) Option {
 	return func(op *Options) {
 		op.domainName = domainName
@@ -108,8 +149,163 @@ func WithServiceNamePort(domainName string, port int) Option {
 	}
 }
 
+// WithResolver discovers the hosts by resolver, which is called again every resolve interval to update
+// the hosts in place, see WithResolveInterval. The hosts of WithHostPort are kept besides the resolved ones.
+func WithResolver(resolver Resolver) Option {
+	return func(op *Options) {
+		op.resolver = resolver
+	}
+}
+
+// WithResolveInterval sets the interval to call the Resolver again, the instances of kitex and the hosts of
+// the auth service are updated in place. It defaults to 0, which resolves the hosts only once in NewClient,
+// except for NewFileResolver which reads its file every DefaultFileResolveInterval. A negative interval never
+// resolves again. A positive interval starts a goroutine per client, which runs until Client.Close is called.
+func WithResolveInterval(d time.Duration) Option {
+	return func(op *Options) {
+		op.resolveInterval = d
+	}
+}
+
 func WithRpcTimeout(d time.Duration) Option {
 	return func(op *Options) {
 		op.RpcTimeout = d
 	}
 }
+
+// WithExpectProtocol specifies the result protocol expected from server for all requests,
+// it can be overridden per request by ExpectProtocolKey in context.
+// ClientProtocol_ColumnarV1 reduces the size and decode cost of large vertex, edge and property results.
+func WithExpectProtocol(protocol ClientProtocol) Option {
+	return func(op *Options) {
+		op.expectProtocol = protocol
+	}
+}
+
+// WithRetryPolicy enables retrying of the queries failed with the error codes allowed by policy.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(op *Options) {
+		op.retryPolicy = policy.withDefaults()
+	}
+}
+
+// WithBatching coalesces the Submit calls of concurrent goroutines into batch requests. A batch is sent
+// when it has maxBatchSize queries, or window after its first query arrived, whichever comes first.
+// Queries are only coalesced with those of the same table, expected protocol, DebugKey, RouteKey and trace
+// parent. A query leaves its batch when its context ends, and the deadline of a batch is the latest one of
+// its queries.
+func WithBatching(window time.Duration, maxBatchSize int) Option {
+	return func(op *Options) {
+		op.batching = true
+		op.batchWindow = window
+		op.maxBatchSize = maxBatchSize
+	}
+}
+
+// WithInterceptors appends interceptors to the client, the first one is the outermost.
+func WithInterceptors(interceptors ...Interceptor) Option {
+	return func(op *Options) {
+		op.interceptors = append(op.interceptors, interceptors...)
+	}
+}
+
+// WithTracer enables tracing of requests by tracer, eg: oteltracing.NewTracer().
+func WithTracer(tracer Tracer) Option {
+	return func(op *Options) {
+		op.tracer = tracer
+	}
+}
+
+// WithMetrics records the metrics of requests in registry, labelled by table and host:
+// rpc latency, server costs, batch sizes and the number of queries per error code.
+// Use metrics.NewPrometheusRegistry() to expose them to Prometheus.
+func WithMetrics(registry metrics.Registry) Option {
+	return func(op *Options) {
+		op.metrics = registry
+	}
+}
+
+// WithLogger specifies the logger of client. The default one logs nothing but the debug events of requests
+// carrying DebugKey{}, which are written to stdout.
+func WithLogger(logger Logger) Option {
+	return func(op *Options) {
+		op.logger = logger
+	}
+}
+
+// WithLogPayloadLimit specifies the max bytes of each binary payload in logs, non-positive means no limit.
+func WithLogPayloadLimit(limit int) Option {
+	return func(op *Options) {
+		op.logPayloadLimit = limit
+	}
+}
+
+// WithSlowQueryLog records the queries exceeding the latency or cost threshold of opts.
+func WithSlowQueryLog(opts SlowQueryOptions) Option {
+	return func(op *Options) {
+		op.slowQuery = &opts
+	}
+}
+
+// WithLimit limits the requests of client to all tables, it applies after the limit of table if any.
+// The limits apply to each request sent to server, including retries.
+func WithLimit(limit Limit) Option {
+	return func(op *Options) {
+		op.limit = &limit
+	}
+}
+
+// WithTableLimit limits the requests of client to table.
+func WithTableLimit(table string, limit Limit) Option {
+	return func(op *Options) {
+		if op.tableLimits == nil {
+			op.tableLimits = make(map[string]Limit)
+		}
+		op.tableLimits[table] = limit
+	}
+}
+
+// WithCircuitBreaker enables circuit breakers of tables and hosts, which reject requests with
+// ErrorCode_CIRCUIT_OPEN after too many queries failed by the error codes of opts.TripRatios.
+func WithCircuitBreaker(opts BreakerOptions) Option {
+	return func(op *Options) {
+		op.breaker = &opts
+	}
+}
+
+// WithAdaptiveLimit enables the AIMD limit of in-flight write requests of each table, so that bulk writers
+// back off on the backpressure error codes of server. The limit covers all attempts of a request.
+func WithAdaptiveLimit(opts AdaptiveLimitOptions) Option {
+	return func(op *Options) {
+		op.adaptiveLimit = &opts
+	}
+}
+
+// WithHealthCheck ejects the hosts failing in a row from load balancing, and probes them in background to bring
+// them back once healthy, see HealthCheckOptions and Client.HealthSnapshot.
+func WithHealthCheck(opts HealthCheckOptions) Option {
+	return func(op *Options) {
+		op.healthCheck = &opts
+	}
+}
+
+// WithHashRouting routes the requests with a key to the host owning the key on a consistent hash ring of
+// the hosts, which keeps the cache of each host hot for its share of keys. The key is RouteKey in context,
+// eg set to the start vertex of a gremlin builder traversal by WithTraversalRouteKey, and the orm methods set it
+// to the vertex they write. Query text is never parsed for a key.
+// Requests without a key are load balanced as usual. virtualNodes is the number of points of each host on
+// the ring, DefaultVirtualNodes if 0.
+func WithHashRouting(virtualNodes int) Option {
+	return func(op *Options) {
+		op.hashRouting = true
+		op.virtualNodes = virtualNodes
+	}
+}
+
+// WithHedging enables hedged requests for read-only queries, which needs at least 2 hosts in HostPorts.
+// Each attempt of a hedged request is pinned to a host, instead of the one picked by load balancer.
+func WithHedging(opts HedgeOptions) Option {
+	return func(op *Options) {
+		op.hedge = &opts
+	}
+}
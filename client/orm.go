@@ -0,0 +1,124 @@
+// Copyright 2022 Beijing Volcanoengine Technology Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+
+	"github.com/volcengine/vegraph-go-sdk/gerrors"
+	"github.com/volcengine/vegraph-go-sdk/gremlin"
+	"github.com/volcengine/vegraph-go-sdk/structure"
+)
+
+// SaveVertex upserts the vertex described by obj, a struct tagged as the destination of Vertex.BindTo and
+// List.BindTo, eg:
+//
+//	type User struct {
+//		Id   int64  `gremlin:"id"`
+//		Type int32  `gremlin:"type"`
+//		Name string `gremlin:"name"`
+//	}
+//
+// All tagged properties are written, nil pointer fields are skipped.
+func (c *Client) SaveVertex(ctx context.Context, obj interface{}, table ...string) error {
+	v, err := structure.VertexFrom(obj)
+	if err != nil {
+		return gerrors.New(gerrors.ErrorCode_INVALID_REQUEST, err)
+	}
+	id, vtype := vertexIdType(v)
+	t := gremlin.G().AddV().Property(structure.VtxIdKey, id).Property(structure.VtxTypeKey, vtype)
+	return c.submitMutation(ctx, withProperties(t, v.Properties), table...)
+}
+
+// UpdateVertex partially updates an existing vertex described by obj, the properties of nil pointer or nil interface
+// fields are left unchanged, the others are written even if they are zero. Use pointer fields to update some
+// properties only, eg a *int64 pointing to 0 sets the property to 0.
+func (c *Client) UpdateVertex(ctx context.Context, obj interface{}, table ...string) error {
+	v, err := structure.VertexFrom(obj)
+	if err != nil {
+		return gerrors.New(gerrors.ErrorCode_INVALID_REQUEST, err)
+	}
+	if len(v.Properties) == 0 {
+		return nil
+	}
+	id, vtype := vertexIdType(v)
+	return c.submitMutation(ctx, withProperties(gremlin.G().V(id, vtype), v.Properties), table...)
+}
+
+// SaveEdge upserts the edge described by obj, a struct tagged as the destination of Edge.BindTo, eg:
+//
+//	type Follow struct {
+//		From  User   `gremlin:"outV"`
+//		To    User   `gremlin:"inV"`
+//		Type  string `gremlin:"type"`
+//		Since int64  `gremlin:"since"`
+//	}
+//
+// Only the id and type of outV and inV are used, all tagged properties of edge are written.
+func (c *Client) SaveEdge(ctx context.Context, obj interface{}, table ...string) error {
+	e, err := structure.EdgeFrom(obj)
+	if err != nil {
+		return gerrors.New(gerrors.ErrorCode_INVALID_REQUEST, err)
+	}
+	outId, outType := vertexIdType(e.OutV)
+	inId, inType := vertexIdType(e.InV)
+	t := gremlin.G().AddE(e.Type).From(outId, outType).To(inId, inType)
+	return c.submitMutation(ctx, withProperties(t, e.Properties), table...)
+}
+
+// UpdateEdge partially updates an existing edge described by obj, the properties of nil pointer or nil interface
+// fields are left unchanged as UpdateVertex.
+func (c *Client) UpdateEdge(ctx context.Context, obj interface{}, table ...string) error {
+	e, err := structure.EdgeFrom(obj)
+	if err != nil {
+		return gerrors.New(gerrors.ErrorCode_INVALID_REQUEST, err)
+	}
+	if len(e.Properties) == 0 {
+		return nil
+	}
+	outId, outType := vertexIdType(e.OutV)
+	inId, inType := vertexIdType(e.InV)
+	t := gremlin.G().V(outId, outType).OutE(e.Type).
+		Where(gremlin.Anon().InV().Has(structure.VtxIdKey, inId).Has(structure.VtxTypeKey, inType))
+	return c.submitMutation(ctx, withProperties(t, e.Properties), table...)
+}
+
+func (c *Client) submitMutation(ctx context.Context, t *gremlin.Traversal, table ...string) error {
+	query, err := t.Build()
+	if err != nil {
+		return err
+	}
+	if _, ok := ctx.Value(RouteKey{}).(string); !ok {
+		ctx = WithTraversalRouteKey(ctx, t)
+	}
+	_, err = c.Submit(ctx, query, table...)
+	return err
+}
+
+func vertexIdType(v *structure.Vertex) (interface{}, interface{}) {
+	if v.VType == structure.IdTypeStringString {
+		return v.SId, v.SType
+	}
+	return v.Id, v.Type
+}
+
+// withProperties writes properties, the absent ones of nil fields are already dropped by structure.VertexFrom
+// and structure.EdgeFrom.
+func withProperties(t *gremlin.Traversal, properties []*structure.Property) *gremlin.Traversal {
+	for _, ppt := range properties {
+		t = t.Property(ppt.Key, ppt.Value)
+	}
+	return t
+}
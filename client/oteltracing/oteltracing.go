@@ -0,0 +1,134 @@
+// Copyright 2022 Beijing Volcanoengine Technology Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package oteltracing implements client.Tracer with OpenTelemetry, eg:
+//
+//	cli, err := client.NewClient(client.WithTracer(oteltracing.NewTracer()), ...)
+//
+// It is a separate module, so that the SDK doesn't depend on OpenTelemetry unless it is imported. It requires
+// a published version of the SDK, to build it against a local checkout use a workspace at the repository root:
+//
+//	go work init . ./client/oteltracing
+package oteltracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/volcengine/vegraph-go-sdk/client"
+)
+
+const instrumentationName = "github.com/volcengine/vegraph-go-sdk/client"
+
+type Option func(*Options)
+
+type Options struct {
+	provider   trace.TracerProvider
+	propagator propagation.TextMapPropagator
+}
+
+// WithTracerProvider specifies the provider of spans, defaults to the global provider of otel.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(op *Options) {
+		op.provider = provider
+	}
+}
+
+// WithPropagator specifies how trace context is propagated to server, defaults to W3C trace context.
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(op *Options) {
+		op.propagator = propagator
+	}
+}
+
+type tracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+func NewTracer(ops ...Option) client.Tracer {
+	opts := &Options{
+		provider:   otel.GetTracerProvider(),
+		propagator: propagation.TraceContext{},
+	}
+	for _, do := range ops {
+		do(opts)
+	}
+	return &tracer{
+		tracer:     opts.provider.Tracer(instrumentationName),
+		propagator: opts.propagator,
+	}
+}
+
+func (t *tracer) Start(ctx context.Context, name string, attrs ...client.Attribute) (context.Context, client.Span) {
+	ctx, span := t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(convert(attrs)...))
+	return ctx, &otelSpan{span: span}
+}
+
+func (t *tracer) Inject(ctx context.Context, carrier map[string]string) string {
+	t.propagator.Inject(ctx, propagation.MapCarrier(carrier))
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+type otelSpan struct {
+	span trace.Span
+}
+
+func (s *otelSpan) SetAttributes(attrs ...client.Attribute) {
+	s.span.SetAttributes(convert(attrs)...)
+}
+
+func (s *otelSpan) AddEvent(name string, attrs ...client.Attribute) {
+	s.span.AddEvent(name, trace.WithAttributes(convert(attrs)...))
+}
+
+func (s *otelSpan) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+func (s *otelSpan) End() {
+	s.span.End()
+}
+
+func convert(attrs []client.Attribute) []attribute.KeyValue {
+	kvs := make([]attribute.KeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		switch v := attr.Value.(type) {
+		case string:
+			kvs = append(kvs, attribute.String(attr.Key, v))
+		case bool:
+			kvs = append(kvs, attribute.Bool(attr.Key, v))
+		case int64:
+			kvs = append(kvs, attribute.Int64(attr.Key, v))
+		case []string:
+			kvs = append(kvs, attribute.StringSlice(attr.Key, v))
+		case []int64:
+			kvs = append(kvs, attribute.Int64Slice(attr.Key, v))
+		default:
+			kvs = append(kvs, attribute.String(attr.Key, fmt.Sprint(v)))
+		}
+	}
+	return kvs
+}
@@ -0,0 +1,54 @@
+package oteltracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/volcengine/vegraph-go-sdk/client"
+)
+
+func TestTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := NewTracer(WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))))
+
+	ctx, span := tracer.Start(context.Background(), client.SpanName,
+		client.Attribute{Key: client.AttrTable, Value: "test"},
+		client.Attribute{Key: client.AttrBatchSize, Value: int64(2)})
+	carrier := make(map[string]string)
+	traceID := tracer.Inject(ctx, carrier)
+	assert.Len(t, traceID, 32)
+	assert.Contains(t, carrier["traceparent"], traceID)
+
+	span.AddEvent(client.EventAuthRefresh)
+	span.SetAttributes(client.Attribute{Key: client.AttrErrorCodes, Value: []int64{0, 1}},
+		client.Attribute{Key: client.AttrFingerprints, Value: []string{"g.V()"}})
+	span.RecordError(errors.New("failed"))
+	span.End()
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, client.SpanName, spans[0].Name())
+	assert.Equal(t, traceID, spans[0].SpanContext().TraceID().String())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	attrs := make(map[string]interface{})
+	for _, kv := range spans[0].Attributes() {
+		attrs[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	assert.Equal(t, "test", attrs[client.AttrTable])
+	assert.Equal(t, int64(2), attrs[client.AttrBatchSize])
+	assert.Equal(t, []int64{0, 1}, attrs[client.AttrErrorCodes])
+	assert.Equal(t, []string{"g.V()"}, attrs[client.AttrFingerprints])
+	assert.Equal(t, client.EventAuthRefresh, spans[0].Events()[0].Name)
+
+	// not traced without a recording provider
+	carrier = make(map[string]string)
+	ctx, span = NewTracer().Start(context.Background(), client.SpanName)
+	assert.Equal(t, "", NewTracer().Inject(ctx, carrier))
+	span.End()
+}
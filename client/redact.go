@@ -0,0 +1,158 @@
+// Copyright 2022 Beijing Volcanoengine Technology Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cloudwego/kitex/pkg/endpoint"
+
+	"github.com/volcengine/vegraph-go-sdk/authentication"
+	"github.com/volcengine/vegraph-go-sdk/kitex_gen/bytegraph"
+)
+
+const (
+	DefaultLogPayloadLimit = 256
+
+	redacted = "[REDACTED]"
+)
+
+// credentialKeys are the keys of Base.Extra and its user_extra whose values are redacted in logs.
+var credentialKeys = map[string]bool{
+	authentication.PersistPwdKey:     true,
+	authentication.PersistSessionKey: true,
+	authentication.PasswordKey:       true,
+	authentication.SessionKey:        true,
+}
+
+// newLogMiddleware logs each rpc with the request and response at LevelDebug.
+func newLogMiddleware(logger Logger, payloadLimit int) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, req, resp interface{}) (err error) {
+			err = next(ctx, req, resp)
+			if !logger.Enabled(ctx, LevelDebug) {
+				return
+			}
+			var attrs []Attribute
+			if args, ok := req.(*bytegraph.ByteGraphServiceGremlinQueryArgs); ok {
+				attrs = append(attrs, requestAttrs(args.Req, payloadLimit)...)
+			}
+			if result, ok := resp.(*bytegraph.ByteGraphServiceGremlinQueryResult); ok && result.Success != nil {
+				attrs = append(attrs, responseAttrs(result.Success, payloadLimit)...)
+			}
+			if err != nil {
+				attrs = append(attrs, Attribute{Key: "err", Value: err.Error()})
+			}
+			logger.Log(ctx, LevelDebug, "gremlin rpc", attrs...)
+			return
+		}
+	}
+}
+
+func requestAttrs(req *bytegraph.GremlinQueryRequest, payloadLimit int) []Attribute {
+	if req == nil {
+		return nil
+	}
+	attrs := []Attribute{{Key: "table", Value: req.Table}}
+	if len(req.Templates) > 0 {
+		attrs = append(attrs, Attribute{Key: "templates", Value: req.Templates},
+			Attribute{Key: "parameters", Value: fmt.Sprint(req.Parameters)})
+	} else {
+		attrs = append(attrs, Attribute{Key: "queries", Value: req.Queries})
+	}
+	if len(req.BinaryParameters) > 0 {
+		params := make([]map[string]string, len(req.BinaryParameters))
+		for i, binParams := range req.BinaryParameters {
+			params[i] = make(map[string]string, len(binParams))
+			for k, v := range binParams {
+				params[i][k] = truncatePayload(v, payloadLimit)
+			}
+		}
+		attrs = append(attrs, Attribute{Key: "binary_parameters", Value: fmt.Sprint(params)})
+	}
+	if req.Base != nil {
+		attrs = append(attrs, Attribute{Key: "log_id", Value: req.Base.LogID},
+			Attribute{Key: "base_extra", Value: fmt.Sprint(redactExtra(req.Base.Extra))})
+	}
+	return attrs
+}
+
+func responseAttrs(resp *bytegraph.GremlinQueryResponse, payloadLimit int) []Attribute {
+	codes := make([]int64, len(resp.BatchErrCode))
+	for i, code := range resp.BatchErrCode {
+		codes[i] = int64(code)
+	}
+	rets := make([]string, len(resp.BatchBinaryRet))
+	for i, ret := range resp.BatchBinaryRet {
+		rets[i] = truncatePayload(ret, payloadLimit)
+	}
+	attrs := []Attribute{
+		{Key: "err_code", Value: int64(resp.ErrCode)},
+		{Key: "desc", Value: resp.Desc},
+		{Key: "batch_err_codes", Value: codes},
+		{Key: "batch_desc", Value: resp.BatchDesc},
+		{Key: "batch_binary_ret", Value: rets},
+		{Key: "costs", Value: resp.Costs},
+		{Key: "txn_ids", Value: resp.TxnIds},
+	}
+	if resp.BaseResp != nil {
+		attrs = append(attrs, Attribute{Key: "resp_extra", Value: fmt.Sprint(redactExtra(resp.BaseResp.Extra))})
+	}
+	return attrs
+}
+
+// redactExtra returns a copy of extra with credentials redacted, including those in the json of user_extra.
+func redactExtra(extra map[string]string) map[string]string {
+	out := make(map[string]string, len(extra))
+	for k, v := range extra {
+		switch {
+		case credentialKeys[k]:
+			out[k] = redacted
+		case k == authentication.UserExtra:
+			out[k] = redactUserExtra(v)
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
+
+func redactUserExtra(userExtra string) string {
+	kvs := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(userExtra), &kvs); err != nil {
+		return redacted
+	}
+	for k := range kvs {
+		if credentialKeys[k] {
+			kvs[k] = redacted
+		}
+	}
+	b, err := json.Marshal(kvs)
+	if err != nil {
+		return redacted
+	}
+	return string(b)
+}
+
+// truncatePayload renders payload in hex, up to limit bytes of it, a non-positive limit means no limit.
+func truncatePayload(payload []byte, limit int) string {
+	if limit <= 0 || len(payload) <= limit {
+		return hex.EncodeToString(payload)
+	}
+	return fmt.Sprintf("%s...(%d bytes)", hex.EncodeToString(payload[:limit]), len(payload))
+}
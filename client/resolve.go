@@ -0,0 +1,287 @@
+// Copyright 2022 Beijing Volcanoengine Technology Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/discovery"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+)
+
+// Resolver discovers the host ports of the graph service. The hosts of the auth service are derived from
+// them by replacing the port with the one of WithAuthPort.
+type Resolver interface {
+	// Resolve returns the host ports, eg "10.0.0.1:9000". It's called by NewClient, then every resolve interval
+	// in background, see WithResolveInterval, and the previous hosts are kept if it fails or returns nothing.
+	Resolve(ctx context.Context) ([]string, error)
+}
+
+// ResolverFunc is an adapter to use a function as Resolver.
+type ResolverFunc func(ctx context.Context) ([]string, error)
+
+func (f ResolverFunc) Resolve(ctx context.Context) ([]string, error) {
+	return f(ctx)
+}
+
+type staticResolver []string
+
+// NewStaticResolver returns a Resolver of fixed host ports.
+func NewStaticResolver(hostPorts ...string) Resolver {
+	return staticResolver(hostPorts)
+}
+
+func (r staticResolver) Resolve(ctx context.Context) ([]string, error) {
+	return append([]string(nil), r...), nil
+}
+
+// lookupHost and lookupSRV are replaced in tests.
+var (
+	lookupHost = net.DefaultResolver.LookupHost
+	lookupSRV  = net.DefaultResolver.LookupSRV
+)
+
+type dnsResolver struct {
+	domainName string
+	port       int
+}
+
+// NewDNSResolver returns a Resolver of the A and AAAA records of domainName, with the given port.
+func NewDNSResolver(domainName string, port int) Resolver {
+	return &dnsResolver{domainName: domainName, port: port}
+}
+
+func (r *dnsResolver) Resolve(ctx context.Context) ([]string, error) {
+	hosts, err := lookupHost(ctx, r.domainName)
+	if err != nil {
+		return nil, fmt.Errorf("LookupHost(%v): %s", r.domainName, err.Error())
+	}
+	hostPorts := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		hostPorts = append(hostPorts, net.JoinHostPort(net.ParseIP(host).String(), strconv.Itoa(r.port)))
+	}
+	return hostPorts, nil
+}
+
+type srvResolver struct {
+	service, proto, name string
+}
+
+// NewSRVResolver returns a Resolver of the SRV records of _service._proto.name, which carry the ports.
+// Both service and proto are empty to look up name directly.
+func NewSRVResolver(service, proto, name string) Resolver {
+	return &srvResolver{service: service, proto: proto, name: name}
+}
+
+func (r *srvResolver) Resolve(ctx context.Context) ([]string, error) {
+	_, addrs, err := lookupSRV(ctx, r.service, r.proto, r.name)
+	if err != nil {
+		return nil, fmt.Errorf("LookupSRV(%v, %v, %v): %s", r.service, r.proto, r.name, err.Error())
+	}
+	hostPorts := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		hostPorts = append(hostPorts, net.JoinHostPort(strings.TrimSuffix(addr.Target, "."), strconv.Itoa(int(addr.Port))))
+	}
+	return hostPorts, nil
+}
+
+// DefaultFileResolveInterval is the interval to read the file of NewFileResolver again without WithResolveInterval.
+const DefaultFileResolveInterval = 10 * time.Second
+
+type fileResolver struct {
+	path     string
+	interval time.Duration
+}
+
+// NewFileResolver returns a Resolver of the host ports in a local file, one per line. Blank lines and lines
+// starting with # are ignored. The file is read again every DefaultFileResolveInterval, or the interval of
+// WithResolveInterval if set, so it can be updated in place.
+func NewFileResolver(path string) Resolver {
+	return &fileResolver{path: path, interval: DefaultFileResolveInterval}
+}
+
+func (r *fileResolver) defaultInterval() time.Duration {
+	return r.interval
+}
+
+func (r *fileResolver) Resolve(ctx context.Context) ([]string, error) {
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return nil, err
+	}
+	var hostPorts []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		hostPort := strings.TrimSpace(scanner.Text())
+		if hostPort == "" || strings.HasPrefix(hostPort, "#") {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(hostPort); err != nil {
+			return nil, fmt.Errorf("%s:%d: %s", r.path, line, err.Error())
+		}
+		hostPorts = append(hostPorts, hostPort)
+	}
+	return hostPorts, scanner.Err()
+}
+
+var hostResolverSeq int64
+
+// hostResolver keeps the hosts of a client up to date with its Resolver.
+type hostResolver struct {
+	resolver Resolver
+	// static is the host ports of WithHostPort, which are kept besides the resolved ones.
+	static   []string
+	authPort int
+	auth     bool
+	interval time.Duration
+	// name is unique per client, since kitex shares the balancers of resolvers with the same name.
+	name string
+}
+
+// defaultIntervalResolver is implemented by the Resolvers which are resolved again without WithResolveInterval.
+type defaultIntervalResolver interface {
+	defaultInterval() time.Duration
+}
+
+func newHostResolver(resolver Resolver, opts *Options) *hostResolver {
+	interval := opts.resolveInterval
+	if r, ok := resolver.(defaultIntervalResolver); ok && interval == 0 {
+		interval = r.defaultInterval()
+	}
+	return &hostResolver{
+		resolver: resolver,
+		static:   opts.HostPorts,
+		authPort: opts.authPort,
+		auth:     opts.authType == AuthType_PasswordSha256,
+		interval: interval,
+		name:     fmt.Sprintf("%T#%d", resolver, atomic.AddInt64(&hostResolverSeq, 1)),
+	}
+}
+
+// lookup returns the static and the sorted resolved host ports, and the host ports of the auth service if needed.
+func (r *hostResolver) lookup(ctx context.Context) (hostPorts, authHostPorts []string, err error) {
+	resolved, err := r.resolver.Resolve(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(resolved) == 0 {
+		return nil, nil, fmt.Errorf("%T: no host resolved", r.resolver)
+	}
+	sort.Strings(resolved)
+	hostPorts = append(append(hostPorts, r.static...), resolved...)
+	if r.auth {
+		if authHostPorts, err = deriveAuthHostPorts(hostPorts, r.authPort); err != nil {
+			return nil, nil, err
+		}
+	}
+	return hostPorts, authHostPorts, nil
+}
+
+func deriveAuthHostPorts(hostPorts []string, authPort int) ([]string, error) {
+	authHostPorts := make([]string, 0, len(hostPorts))
+	for _, hostPort := range hostPorts {
+		host, _, err := net.SplitHostPort(hostPort)
+		if err != nil {
+			return nil, fmt.Errorf("SplitHostPort(%v): %s", hostPort, err.Error())
+		}
+		authHostPorts = append(authHostPorts, net.JoinHostPort(host, strconv.Itoa(authPort)))
+	}
+	return authHostPorts, nil
+}
+
+// kitexResolver serves the current host ports of c to kitex, which picks up the changes by its balancer
+// refresh (every 5s by default). Removed hosts only get no new calls, the calls in flight are not affected.
+func (r *hostResolver) kitexResolver(c *Client) discovery.Resolver {
+	return &discovery.SynthesizedResolver{
+		ResolveFunc: func(ctx context.Context, key string) (discovery.Result, error) {
+			hostPorts := c.getHostPorts()
+			instances := make([]discovery.Instance, 0, len(hostPorts))
+			for _, hostPort := range hostPorts {
+				instances = append(instances, discovery.NewInstance("tcp", hostPort, discovery.DefaultWeight, nil))
+			}
+			return discovery.Result{Cacheable: true, CacheKey: r.name, Instances: instances}, nil
+		},
+		NameFunc:   func() string { return r.name },
+		TargetFunc: func(ctx context.Context, target rpcinfo.EndpointInfo) string { return r.name },
+	}
+}
+
+// watch resolves the hosts every interval until c is closed. The previous hosts are kept on failure.
+func (r *hostResolver) watch(c *Client) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.closed:
+			return
+		case <-ticker.C:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), r.interval)
+		hostPorts, authHostPorts, err := r.lookup(ctx)
+		cancel()
+		if err != nil {
+			c.logEvent(context.Background(), LevelWarn, "resolve hosts failed", func() []Attribute {
+				return []Attribute{{Key: "resolver", Value: r.name}, {Key: "err", Value: err.Error()}}
+			})
+			continue
+		}
+		if equalStrings(hostPorts, c.getHostPorts()) {
+			continue
+		}
+		c.setHostPorts(hostPorts, authHostPorts)
+		c.logEvent(context.Background(), LevelInfo, "hosts resolved", func() []Attribute {
+			return []Attribute{{Key: "resolver", Value: r.name}, {Key: "hosts", Value: hostPorts}}
+		})
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Client) setHostPorts(hostPorts, authHostPorts []string) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.hostPorts = hostPorts
+	if setter, ok := c.auth.(interface{ SetHostPorts([]string) }); ok && len(authHostPorts) > 0 {
+		setter.SetHostPorts(authHostPorts)
+	}
+}
+
+// Close stops the background work of the client, eg the re-resolution of Resolver and the probes of WithHealthCheck.
+// Requests can still be sent after Close, but the hosts are not updated or probed any more.
+func (c *Client) Close() error {
+	c.closeOnce.Do(func() { close(c.closed) })
+	return nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2022 Beijing Volcanoengine Technology Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/volcengine/vegraph-go-sdk/gerrors"
+	"github.com/volcengine/vegraph-go-sdk/kitex_gen/bytegraph"
+	"github.com/volcengine/vegraph-go-sdk/structure"
+)
+
+// Result is the outcome of a single query, with the metadata returned by server.
+// Cost, TxnId, TxnTs and RespExtra are zero if the request failed before reaching server.
+type Result struct {
+	Element structure.Element
+	Err     error
+	structure.Extra
+}
+
+// SubmitEx works like Submit, but returns the query metadata as well.
+func (c *Client) SubmitEx(ctx context.Context, query string, table ...string) *Result {
+	if c.batcher != nil {
+		reqTable, err := c.reqTable(table...)
+		if err != nil {
+			return &Result{Err: err}
+		}
+		return c.batcher.submit(ctx, query, reqTable)
+	}
+	return c.BatchSubmitEx(ctx, []string{query}, table...)[0]
+}
+
+// BatchSubmitEx works like BatchSubmit, but returns one Result per query, in the order of queries.
+func (c *Client) BatchSubmitEx(ctx context.Context, queries []string, table ...string) []*Result {
+	request := &bytegraph.GremlinQueryRequest{
+		Queries:   queries,
+		UseBinary: true,
+	}
+	reqTable, err := c.reqTable(table...)
+	if err != nil {
+		return errorResults(err, len(queries))
+	}
+	request.Table = reqTable
+	elems, extras, errs := c.submitBatchRequest(ctx, request)
+	return newResults(elems, extras, errs, len(queries))
+}
+
+func newResults(elems []structure.Element, extras []*structure.Extra, errs []error, size int) []*Result {
+	results := make([]*Result, size)
+	for i := range results {
+		results[i] = &Result{}
+		if i < len(elems) {
+			results[i].Element = elems[i]
+		}
+		if i < len(extras) && extras[i] != nil {
+			results[i].Extra = *extras[i]
+		}
+		if i < len(errs) {
+			results[i].Err = errs[i]
+		} else {
+			results[i].Err = gerrors.New(gerrors.ErrorCode_SYSTEM_ERROR, fmt.Errorf("unexpected error number returned by submitBatchRequest: %d", len(errs)))
+		}
+	}
+	return results
+}
+
+func errorResults(err error, size int) []*Result {
+	if size == 0 {
+		size = 1
+	}
+	results := make([]*Result, size)
+	for i := range results {
+		results[i] = &Result{Err: err}
+	}
+	return results
+}
@@ -0,0 +1,180 @@
+// Copyright 2022 Beijing Volcanoengine Technology Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/volcengine/vegraph-go-sdk/gerrors"
+	"github.com/volcengine/vegraph-go-sdk/kitex_gen/bytegraph"
+	"github.com/volcengine/vegraph-go-sdk/structure"
+)
+
+const (
+	DefaultRetryMaxAttempts    = 3
+	DefaultRetryInitialBackoff = time.Millisecond * 10
+	DefaultRetryMaxBackoff     = time.Second
+	DefaultRetryMultiplier     = 2.0
+	DefaultRetryJitter         = 0.2
+)
+
+// RetryPolicy controls how queries failed with retryable error codes are retried.
+// Only the failed queries of a batch are resent, zero fields are replaced with defaults.
+type RetryPolicy struct {
+	// MaxAttempts is the max number of attempts of a query, including the first one.
+	MaxAttempts int
+	// InitialBackoff is the wait time before the first retry, it is multiplied by Multiplier
+	// for each further retry, up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter randomly shortens each backoff by up to the given fraction of it, in range [0, 1].
+	Jitter float64
+	// RetryErrorCodes is the allowlist of error codes to retry, defaults to gerrors.DefaultRetryErrorCodes.
+	RetryErrorCodes []gerrors.ErrorCode
+	// Budget is the total time of all attempts including backoffs, the attempts are sent with a context
+	// timing out after Budget, or at the deadline of the caller's context if it is earlier. A retry is not
+	// started if it cannot finish its backoff before then. Zero means only the context deadline applies.
+	Budget time.Duration
+}
+
+func (p RetryPolicy) withDefaults() *RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultRetryMaxAttempts
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = DefaultRetryInitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = DefaultRetryMaxBackoff
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = DefaultRetryMultiplier
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		p.Jitter = DefaultRetryJitter
+	}
+	if len(p.RetryErrorCodes) == 0 {
+		p.RetryErrorCodes = gerrors.DefaultRetryErrorCodes
+	}
+	return &p
+}
+
+// backoff returns the wait time before the given retry, starting from 1.
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	backoff := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(retry-1))
+	if backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+	backoff -= backoff * p.Jitter * rand.Float64()
+	return time.Duration(backoff)
+}
+
+func (p *RetryPolicy) retryIndexes(errs []error) []int {
+	var indexes []int
+	for i, err := range errs {
+		if err != nil && gerrors.Contains(p.RetryErrorCodes, gerrors.Code(err)) {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+func (c *Client) submitWithRetry(ctx context.Context, request *bytegraph.GremlinQueryRequest, policy *RetryPolicy) ([]structure.Element, []*structure.Extra, []error) {
+	if policy.Budget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, policy.Budget)
+		defer cancel()
+	}
+	deadline, hasDeadline := ctx.Deadline()
+	elems, extras, errs := c.doSubmitBatchRequest(ctx, request)
+	for retry := 1; retry < policy.MaxAttempts; retry++ {
+		indexes := policy.retryIndexes(errs)
+		if len(indexes) == 0 {
+			break
+		}
+		backoff := policy.backoff(retry)
+		if hasDeadline && time.Now().Add(backoff).After(deadline) {
+			break
+		}
+		if err := sleepContext(ctx, backoff); err != nil {
+			break
+		}
+		spanFromContext(ctx).AddEvent(EventRetry, Attribute{Key: AttrAttempt, Value: int64(retry + 1)},
+			Attribute{Key: AttrBatchSize, Value: int64(len(indexes))})
+		c.logEvent(ctx, LevelDebug, "retrying queries", func() []Attribute {
+			return []Attribute{{Key: "table", Value: request.Table}, {Key: "attempt", Value: int64(retry + 1)},
+				{Key: "size", Value: int64(len(indexes))}}
+		})
+		subElems, subExtras, subErrs := c.doSubmitBatchRequest(ctx, subRequest(request, indexes))
+		if elems == nil {
+			elems = make([]structure.Element, len(errs))
+		}
+		if extras == nil {
+			extras = make([]*structure.Extra, len(errs))
+		}
+		for i, idx := range indexes {
+			if i < len(subElems) {
+				elems[idx] = subElems[i]
+			}
+			if i < len(subExtras) {
+				extras[idx] = subExtras[i]
+			}
+			if i < len(subErrs) {
+				errs[idx] = subErrs[i]
+			}
+		}
+	}
+	return elems, extras, errs
+}
+
+// subRequest returns a copy of request which only carries the queries at indexes.
+func subRequest(request *bytegraph.GremlinQueryRequest, indexes []int) *bytegraph.GremlinQueryRequest {
+	sub := *request
+	if len(request.Templates) > 0 {
+		sub.Templates = make([]string, 0, len(indexes))
+		sub.Parameters = make([]map[string]*bytegraph.Value, 0, len(indexes))
+		sub.BinaryParameters = make([]map[string][]byte, 0, len(indexes))
+		for _, idx := range indexes {
+			sub.Templates = append(sub.Templates, request.Templates[idx])
+			if idx < len(request.Parameters) {
+				sub.Parameters = append(sub.Parameters, request.Parameters[idx])
+			}
+			if idx < len(request.BinaryParameters) {
+				sub.BinaryParameters = append(sub.BinaryParameters, request.BinaryParameters[idx])
+			}
+		}
+		return &sub
+	}
+	sub.Queries = make([]string, 0, len(indexes))
+	for _, idx := range indexes {
+		sub.Queries = append(sub.Queries, request.Queries[idx])
+	}
+	return &sub
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
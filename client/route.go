@@ -0,0 +1,136 @@
+// Copyright 2022 Beijing Volcanoengine Technology Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/volcengine/vegraph-go-sdk/gremlin"
+)
+
+// DefaultVirtualNodes is the number of points of each host on the hash ring.
+const DefaultVirtualNodes = 160
+
+// RouteKey is the context key to specify the key of a single request for WithHashRouting,
+// eg: context.WithValue(ctx, RouteKey{}, "user:123")
+type RouteKey struct {
+}
+
+// WithTraversalRouteKey sets RouteKey in ctx to the start vertex of t as "id:type", see gremlin.Traversal.StartVertex.
+// ctx is returned as it is if t doesn't start from a single vertex.
+func WithTraversalRouteKey(ctx context.Context, t *gremlin.Traversal) context.Context {
+	id, vtype, ok := t.StartVertex()
+	if !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, RouteKey{}, fmt.Sprintf("%v:%v", id, vtype))
+}
+
+// hashRing places each host at virtualNodes points of a 64-bit ring, and a key belongs to the first point
+// clockwise of its hash. When a host is added or removed, only the keys next to its points move.
+type hashRing struct {
+	hosts  []string
+	points []uint64
+	owners []string
+}
+
+func hashKey(key string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	// fnv is poorly mixed for keys differing in the last bytes, finalize it as in murmur3
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+func newHashRing(hosts []string, virtualNodes int) *hashRing {
+	r := &hashRing{hosts: hosts}
+	type point struct {
+		hash  uint64
+		owner string
+	}
+	points := make([]point, 0, len(hosts)*virtualNodes)
+	for _, host := range hosts {
+		for i := 0; i < virtualNodes; i++ {
+			points = append(points, point{hash: hashKey(host + "#" + strconv.Itoa(i)), owner: host})
+		}
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].hash < points[j].hash })
+	r.points = make([]uint64, len(points))
+	r.owners = make([]string, len(points))
+	for i, p := range points {
+		r.points[i], r.owners[i] = p.hash, p.owner
+	}
+	return r
+}
+
+func (r *hashRing) get(key string) string {
+	if len(r.points) == 0 {
+		return ""
+	}
+	hash := hashKey(key)
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= hash })
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.owners[i]
+}
+
+// router pins requests with a route key to the host owning the key on the hash ring of the current hosts.
+type router struct {
+	virtualNodes int
+	hosts        func() []string
+
+	mu   sync.Mutex
+	ring *hashRing
+}
+
+func newRouter(virtualNodes int, hosts func() []string) *router {
+	if virtualNodes <= 0 {
+		virtualNodes = DefaultVirtualNodes
+	}
+	return &router{virtualNodes: virtualNodes, hosts: hosts}
+}
+
+// currentRing returns the ring of the current hosts, it's rebuilt after the hosts are resolved again.
+func (r *router) currentRing() *hashRing {
+	hosts := r.hosts()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ring == nil || !equalStrings(r.ring.hosts, hosts) {
+		r.ring = newHashRing(hosts, r.virtualNodes)
+	}
+	return r.ring
+}
+
+func (r *router) intercept(next Endpoint) Endpoint {
+	return func(ctx context.Context, call *Call) {
+		if key, ok := ctx.Value(RouteKey{}).(string); ok {
+			if host := r.currentRing().get(key); host != "" {
+				ctx = withHostPort(ctx, host)
+			}
+		}
+		next(ctx, call)
+	}
+}
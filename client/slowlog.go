@@ -0,0 +1,155 @@
+// Copyright 2022 Beijing Volcanoengine Technology Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/volcengine/vegraph-go-sdk/gerrors"
+	"github.com/volcengine/vegraph-go-sdk/gremlin"
+)
+
+// SlowQueryOptions controls which queries are recorded as slow, see WithSlowQueryLog.
+type SlowQueryOptions struct {
+	// LatencyThreshold is the min latency of request to be slow, including rpc and decoding. Zero disables it.
+	LatencyThreshold time.Duration
+	// CostThreshold is the min cost returned by server for a query to be slow. Zero disables it.
+	CostThreshold int64
+	// SampleRate is the fraction of slow queries recorded, in range (0, 1], defaults to 1.
+	SampleRate float64
+	// MaxPerSecond caps the records per second, zero means no cap.
+	MaxPerSecond int
+	// Handler receives the records, defaults to logging them at LevelWarn by the Logger of client, see WithLogger.
+	Handler func(ctx context.Context, record *SlowQuery)
+}
+
+// SlowQuery is the record of a slow query. The latencies are of the whole request the query was sent in.
+type SlowQuery struct {
+	// Fingerprint is the query normalized by gremlin.Fingerprint, without the values in it.
+	Fingerprint   string
+	Table         string
+	Host          string
+	BatchSize     int
+	Latency       time.Duration
+	RPCLatency    time.Duration
+	DecodeLatency time.Duration
+	Cost          int64
+	// ResultSize is the bytes of the encoded result.
+	ResultSize int
+	ErrCode    gerrors.ErrorCode
+}
+
+func (q *SlowQuery) attributes() []Attribute {
+	return []Attribute{
+		{Key: "fingerprint", Value: q.Fingerprint},
+		{Key: "table", Value: q.Table},
+		{Key: "host", Value: q.Host},
+		{Key: "batch_size", Value: int64(q.BatchSize)},
+		{Key: "latency", Value: q.Latency},
+		{Key: "rpc_latency", Value: q.RPCLatency},
+		{Key: "decode_latency", Value: q.DecodeLatency},
+		{Key: "cost", Value: q.Cost},
+		{Key: "result_size", Value: int64(q.ResultSize)},
+		{Key: "err_code", Value: q.ErrCode.String()},
+	}
+}
+
+type slowQueryLog struct {
+	opts SlowQueryOptions
+	// enabled skips building the records nobody receives, it's nil with a custom Handler.
+	enabled func(ctx context.Context) bool
+
+	mu     sync.Mutex
+	second int64
+	count  int
+}
+
+func newSlowQueryLog(opts SlowQueryOptions, c *Client) *slowQueryLog {
+	if opts.SampleRate <= 0 || opts.SampleRate > 1 {
+		opts.SampleRate = 1
+	}
+	l := &slowQueryLog{opts: opts}
+	if opts.Handler == nil {
+		l.opts.Handler = func(ctx context.Context, record *SlowQuery) {
+			c.logEvent(ctx, LevelWarn, "slow query", record.attributes)
+		}
+		l.enabled = func(ctx context.Context) bool {
+			return c.logger.Enabled(ctx, LevelWarn)
+		}
+	}
+	return l
+}
+
+func (l *slowQueryLog) intercept(next Endpoint) Endpoint {
+	return func(ctx context.Context, call *Call) {
+		next(ctx, call)
+		if l.enabled != nil && !l.enabled(ctx) {
+			return
+		}
+		latency := call.RPCLatency + call.DecodeLatency
+		slowLatency := l.opts.LatencyThreshold > 0 && latency >= l.opts.LatencyThreshold
+		queries := call.Queries()
+		for i, query := range queries {
+			var cost int64
+			if i < len(call.Extras) && call.Extras[i] != nil {
+				cost = call.Extras[i].Cost
+			}
+			slowCost := l.opts.CostThreshold > 0 && cost >= l.opts.CostThreshold
+			if !(slowLatency || slowCost) || !l.sample() {
+				continue
+			}
+			record := &SlowQuery{
+				Fingerprint:   gremlin.Fingerprint(query),
+				Table:         call.Table,
+				Host:          call.Host,
+				BatchSize:     len(queries),
+				Latency:       latency,
+				RPCLatency:    call.RPCLatency,
+				DecodeLatency: call.DecodeLatency,
+				Cost:          cost,
+			}
+			if call.Response != nil && i < len(call.Response.BatchBinaryRet) {
+				record.ResultSize = len(call.Response.BatchBinaryRet[i])
+			}
+			if i < len(call.Errs) {
+				record.ErrCode = gerrors.Code(call.Errs[i])
+			}
+			l.opts.Handler(ctx, record)
+		}
+	}
+}
+
+// sample reports whether a slow query should be recorded, by SampleRate and MaxPerSecond.
+func (l *slowQueryLog) sample() bool {
+	if l.opts.SampleRate < 1 && rand.Float64() >= l.opts.SampleRate {
+		return false
+	}
+	if l.opts.MaxPerSecond <= 0 {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now := time.Now().Unix(); now != l.second {
+		l.second, l.count = now, 0
+	}
+	if l.count >= l.opts.MaxPerSecond {
+		return false
+	}
+	l.count++
+	return true
+}
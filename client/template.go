@@ -0,0 +1,149 @@
+// Copyright 2022 Beijing Volcanoengine Technology Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/volcengine/vegraph-go-sdk/gerrors"
+	"github.com/volcengine/vegraph-go-sdk/kitex_gen/bytegraph"
+	"github.com/volcengine/vegraph-go-sdk/structure"
+)
+
+// SubmitTemplate submits a parameterized query, params are bound to the placeholders of template on server side.
+// A placeholder is the bare parameter name as a Gremlin binding, eg `g.V().has('id', vid).has('type', vtype)`
+// with params {"vid": 1, "vtype": 1001}, which is also the syntax of gremlin.Traversal.Template.
+// Supported param value types are bool, int/int8/int16/int32/int64, uint8/uint16/uint32, float32/float64, string,
+// []byte and the scalar elements of structure package. []byte values are sent as binary parameters.
+func (c *Client) SubmitTemplate(ctx context.Context, template string, params map[string]interface{}, table ...string) (structure.Element, error) {
+	request, err := c.newTemplateRequest([]string{template}, []map[string]interface{}{params}, table...)
+	if err != nil {
+		return nil, err
+	}
+	elems, _, errs := c.submitBatchRequest(ctx, request)
+	return firstResult(elems, errs)
+}
+
+// BatchSubmitTemplate submits several parameterized queries in one request, params[i] is bound to templates[i].
+func (c *Client) BatchSubmitTemplate(ctx context.Context, templates []string, params []map[string]interface{}, table ...string) ([]structure.Element, []error) {
+	request, err := c.newTemplateRequest(templates, params, table...)
+	if err != nil {
+		return nil, []error{err}
+	}
+	elems, _, errs := c.submitBatchRequest(ctx, request)
+	return elems, batchErrors(errs)
+}
+
+func (c *Client) newTemplateRequest(templates []string, params []map[string]interface{}, table ...string) (*bytegraph.GremlinQueryRequest, error) {
+	if len(templates) != len(params) {
+		return nil, gerrors.New(gerrors.ErrorCode_INVALID_REQUEST, fmt.Errorf("number of templates(%d) and parameters(%d) not equal", len(templates), len(params)))
+	}
+	reqTable, err := c.reqTable(table...)
+	if err != nil {
+		return nil, err
+	}
+	request := &bytegraph.GremlinQueryRequest{
+		Table:            reqTable,
+		Templates:        templates,
+		Parameters:       make([]map[string]*bytegraph.Value, len(templates)),
+		BinaryParameters: make([]map[string][]byte, len(templates)),
+		UseBinary:        true,
+	}
+	for i, param := range params {
+		values, binaries, err := TemplateParameters(param)
+		if err != nil {
+			return nil, err
+		}
+		request.Parameters[i] = values
+		request.BinaryParameters[i] = binaries
+	}
+	return request, nil
+}
+
+// TemplateParameters converts Go values to the parameters of a template request.
+func TemplateParameters(params map[string]interface{}) (map[string]*bytegraph.Value, map[string][]byte, error) {
+	values := make(map[string]*bytegraph.Value, len(params))
+	binaries := make(map[string][]byte)
+	for name, param := range params {
+		if bts, ok := param.([]byte); ok {
+			binaries[name] = bts
+			continue
+		}
+		value, err := toValue(param)
+		if err != nil {
+			return nil, nil, gerrors.New(gerrors.ErrorCode_INVALID_REQUEST, fmt.Errorf("template parameter %s: %w", name, err))
+		}
+		values[name] = value
+	}
+	return values, binaries, nil
+}
+
+func toValue(param interface{}) (*bytegraph.Value, error) {
+	value := &bytegraph.Value{}
+	switch v := param.(type) {
+	case bool:
+		value.BoolValue = &v
+	case structure.Bool:
+		b := bool(v)
+		value.BoolValue = &b
+	case int8:
+		i32 := int32(v)
+		value.IntValue = &i32
+	case int16:
+		i32 := int32(v)
+		value.IntValue = &i32
+	case uint8:
+		i32 := int32(v)
+		value.IntValue = &i32
+	case uint16:
+		i32 := int32(v)
+		value.IntValue = &i32
+	case int32:
+		value.IntValue = &v
+	case structure.Int32:
+		i32 := int32(v)
+		value.IntValue = &i32
+	case int:
+		i64 := int64(v)
+		value.Int64Value = &i64
+	case uint32:
+		i64 := int64(v)
+		value.Int64Value = &i64
+	case int64:
+		value.Int64Value = &v
+	case structure.Int64:
+		i64 := int64(v)
+		value.Int64Value = &i64
+	case float32:
+		f := float64(v)
+		value.FloatValue = &f
+	case structure.Float32:
+		f := float64(v)
+		value.FloatValue = &f
+	case float64:
+		value.DoubleValue = &v
+	case structure.Float64:
+		f := float64(v)
+		value.DoubleValue = &f
+	case string:
+		value.StringValue = []byte(v)
+	case structure.String:
+		value.StringValue = []byte(v)
+	default:
+		return nil, fmt.Errorf("unsupported parameter type %T", param)
+	}
+	return value, nil
+}
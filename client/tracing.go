@@ -0,0 +1,143 @@
+// Copyright 2022 Beijing Volcanoengine Technology Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+
+	"github.com/volcengine/vegraph-go-sdk/gerrors"
+	"github.com/volcengine/vegraph-go-sdk/gremlin"
+	"github.com/volcengine/vegraph-go-sdk/kitex_gen/base"
+	"github.com/volcengine/vegraph-go-sdk/kitex_gen/bytegraph"
+	"github.com/volcengine/vegraph-go-sdk/structure"
+)
+
+const (
+	SpanName = "bytegraph.GremlinQuery"
+
+	AttrDBSystem     = "db.system"
+	AttrTable        = "bytegraph.table"
+	AttrBatchSize    = "bytegraph.batch_size"
+	AttrFingerprints = "bytegraph.query.fingerprints"
+	AttrErrorCodes   = "bytegraph.error_codes"
+	AttrCosts        = "bytegraph.costs"
+	AttrAttempt      = "bytegraph.attempt"
+	AttrError        = "error"
+
+	EventRetry       = "bytegraph.retry"
+	EventAuthRefresh = "bytegraph.auth.refresh"
+
+	// maxSpanFingerprints limits the distinct fingerprints recorded for a large batch.
+	maxSpanFingerprints = 16
+)
+
+// Attribute is a key value pair of span, Value is one of string, bool, int64, []string and []int64.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// Span is a span started by Tracer, it is not used after End.
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	AddEvent(name string, attrs ...Attribute)
+	// RecordError records err and marks the span as failed.
+	RecordError(err error)
+	End()
+}
+
+// Tracer creates a span for each Submit, BatchSubmit and their variants, which covers retries of the request.
+// See module client/oteltracing for an OpenTelemetry implementation.
+type Tracer interface {
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+	// Inject writes the trace context of ctx into carrier, and returns the trace id, or "" if ctx is not traced.
+	Inject(ctx context.Context, carrier map[string]string) string
+}
+
+type spanKey struct{}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...Attribute)    {}
+func (noopSpan) AddEvent(string, ...Attribute) {}
+func (noopSpan) RecordError(error)             {}
+func (noopSpan) End()                          {}
+
+// spanFromContext returns the span of the request, or a no-op span if tracing is disabled.
+func spanFromContext(ctx context.Context) Span {
+	if span, ok := ctx.Value(spanKey{}).(Span); ok {
+		return span
+	}
+	return noopSpan{}
+}
+
+// startSpan starts the span of request, and propagates the trace context to server in request.Base:
+// the context is injected into Base.Extra, and the trace id is used as Base.LogID if it is not set.
+func (c *Client) startSpan(ctx context.Context, request *bytegraph.GremlinQueryRequest) (context.Context, Span) {
+	ctx, span := c.tracer.Start(ctx, SpanName,
+		Attribute{Key: AttrDBSystem, Value: "bytegraph"},
+		Attribute{Key: AttrTable, Value: request.Table},
+		Attribute{Key: AttrBatchSize, Value: int64(requestBatchSize(request))},
+		Attribute{Key: AttrFingerprints, Value: fingerprints(request)},
+	)
+	if request.Base == nil {
+		request.Base = &base.Base{}
+	}
+	if request.Base.Extra == nil {
+		request.Base.Extra = make(map[string]string)
+	}
+	if traceID := c.tracer.Inject(ctx, request.Base.Extra); traceID != "" && request.Base.LogID == "" {
+		request.Base.LogID = traceID
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func fingerprints(request *bytegraph.GremlinQueryRequest) []string {
+	var prints []string
+	seen := make(map[string]bool)
+	for _, query := range (&Call{Request: request}).Queries() {
+		fp := gremlin.Fingerprint(query)
+		if seen[fp] {
+			continue
+		}
+		if len(prints) == maxSpanFingerprints {
+			break
+		}
+		seen[fp] = true
+		prints = append(prints, fp)
+	}
+	return prints
+}
+
+// endSpan records the per-query error codes and costs, and the first error if any query failed.
+func endSpan(span Span, extras []*structure.Extra, errs []error) {
+	codes := make([]int64, len(errs))
+	costs := make([]int64, len(errs))
+	var firstErr error
+	for i, err := range errs {
+		codes[i] = int64(gerrors.Code(err))
+		if i < len(extras) && extras[i] != nil {
+			costs[i] = extras[i].Cost
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	span.SetAttributes(Attribute{Key: AttrErrorCodes, Value: codes}, Attribute{Key: AttrCosts, Value: costs})
+	if firstErr != nil {
+		span.RecordError(firstErr)
+	}
+	span.End()
+}
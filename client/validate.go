@@ -0,0 +1,67 @@
+// Copyright 2022 Beijing Volcanoengine Technology Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/volcengine/vegraph-go-sdk/gerrors"
+	"github.com/volcengine/vegraph-go-sdk/kitex_gen/bytegraph"
+)
+
+// ProtocolError describes a response not matching its request, it is the cause of errors with
+// gerrors.ErrorCode_PROTOCOL_ERROR, and can be retrieved by errors.As for debugging:
+//
+//	var perr *client.ProtocolError
+//	if errors.As(err, &perr) {
+//		log.Printf("bad response: %+v", perr.Response)
+//	}
+type ProtocolError struct {
+	Reason string
+	// Response is the raw response, it is shared by all queries of the batch and must not be modified.
+	Response *bytegraph.GremlinQueryResponse
+}
+
+func (e *ProtocolError) Error() string {
+	return "invalid response: " + e.Reason
+}
+
+func newProtocolError(resp *bytegraph.GremlinQueryResponse, format string, args ...interface{}) error {
+	return gerrors.New(gerrors.ErrorCode_PROTOCOL_ERROR, &ProtocolError{Reason: fmt.Sprintf(format, args...), Response: resp})
+}
+
+// validateResponse checks whether the results of resp can be mapped to the queries of a batch request,
+// the returned error applies to all queries. Errors of single query are checked when its result is decoded.
+func validateResponse(resp *bytegraph.GremlinQueryResponse, batchSize int) error {
+	if resp == nil {
+		return newProtocolError(resp, "nil response")
+	}
+	// the request failed as a whole, eg: table not exist
+	if len(resp.BatchErrCode) == 0 && resp.ErrCode != bytegraph.ErrorCode_SUCCESS {
+		return gerrors.New(gerrors.ErrorCode(resp.ErrCode), errors.New(resp.Desc))
+	}
+	if len(resp.BatchErrCode) != batchSize {
+		return newProtocolError(resp, "batch size of request(%d) and response(%d) not equal", batchSize, len(resp.BatchErrCode))
+	}
+	return nil
+}
+
+func batchDesc(resp *bytegraph.GremlinQueryResponse, i int) string {
+	if i < len(resp.BatchDesc) {
+		return resp.BatchDesc[i]
+	}
+	return resp.BatchErrCode[i].String()
+}
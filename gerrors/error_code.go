@@ -32,6 +32,16 @@ var (
 	ErrUnexpectedEOB = errors.New("unexpected end of buffer")
 )
 
+var (
+	ErrEmptyResult  = errors.New("query result is empty")
+	ErrElemNotExist = errors.New("element not exist")
+
+	ErrRateLimited        = errors.New("request exceeds the client side qps limit")
+	ErrConcurrencyLimited = errors.New("request exceeds the client side in-flight limit")
+	ErrCircuitOpen        = errors.New("circuit breaker is open")
+	ErrHostEjected        = errors.New("host is ejected by health checking")
+)
+
 type ErrorCode int32
 
 // DefaultRetryErrorCodes 常见需要重试的一些错误
@@ -71,13 +81,26 @@ const (
 	ErrorCode_NOT_SETED               = ErrorCode(bytegraph.ErrorCode_NOT_SETED)
 	ErrorCode_AUTH_FAILED             = ErrorCode(bytegraph.ErrorCode_AUTH_FAILED)
 
+	// error code for requests rejected by open circuit breakers, see ErrCircuitOpen
+	ErrorCode_CIRCUIT_OPEN ErrorCode = 251
+	// error code for requests rejected by client side limits, see ErrRateLimited and ErrConcurrencyLimited
+	ErrorCode_CLIENT_LIMITED ErrorCode = 252
+	// error code for response not matching the request, eg: missing results of some queries
+	ErrorCode_PROTOCOL_ERROR ErrorCode = 253
 	// error code for all rpc framework error
 	ErrorCode_NETWORK_ERROR ErrorCode = 254
 )
 
 func (p ErrorCode) String() string {
-	if p == ErrorCode_NETWORK_ERROR {
+	switch p {
+	case ErrorCode_NETWORK_ERROR:
 		return "NETWORK_ERROR"
+	case ErrorCode_PROTOCOL_ERROR:
+		return "PROTOCOL_ERROR"
+	case ErrorCode_CLIENT_LIMITED:
+		return "CLIENT_LIMITED"
+	case ErrorCode_CIRCUIT_OPEN:
+		return "CIRCUIT_OPEN"
 	}
 	return strings.TrimPrefix(bytegraph.ErrorCode(p).String(), "ErrorCode_")
 }
@@ -15,6 +15,7 @@
 package gerrors
 
 import (
+	"errors"
 	"fmt"
 )
 
@@ -57,6 +58,10 @@ func (e *gremlinError) ErrCause() error {
 	return e.cause
 }
 
+func (e *gremlinError) Unwrap() error {
+	return e.cause
+}
+
 func DuplicateErr(err error, times int) []error {
 	errs := make([]error, times)
 	for i := 0; i < times; i++ {
@@ -64,3 +69,26 @@ func DuplicateErr(err error, times int) []error {
 	}
 	return errs
 }
+
+// Code returns the ErrorCode carried by err, ErrorCode_SUCCESS for nil and ErrorCode_UNKNOWN_ERROR
+// for errors not created by this package.
+func Code(err error) ErrorCode {
+	if err == nil {
+		return ErrorCode_SUCCESS
+	}
+	var gerr GremlinError
+	if errors.As(err, &gerr) {
+		return gerr.ErrCode()
+	}
+	return ErrorCode_UNKNOWN_ERROR
+}
+
+// Contains reports whether code is in codes.
+func Contains(codes []ErrorCode, code ErrorCode) bool {
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+// Copyright 2022 Beijing Volcanoengine Technology Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gremlin
+
+import (
+	"strings"
+)
+
+// Fingerprint normalizes query text for grouping queries of the same shape, eg in traces and logs:
+// string and number literals are replaced with `?`, and runs of whitespace are collapsed.
+//
+//	g.V().has('id', 1).has('type', 1001).outE('like').limit(10)
+//
+// is fingerprinted as
+//
+//	g.V().has(?, ?).has(?, ?).outE(?).limit(?)
+func Fingerprint(query string) string {
+	var b strings.Builder
+	b.Grow(len(query))
+	space := false
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			space = true
+			i++
+			continue
+		case c == '\'' || c == '"':
+			i = skipString(query, i)
+			c = '?'
+		case isDigit(c) && !isIdentEnd(query, i):
+			i = skipNumber(query, i)
+			c = '?'
+		case c == '-' && i+1 < len(query) && isDigit(query[i+1]) && isOperandStart(b.String()):
+			i = skipNumber(query, i+1)
+			c = '?'
+		default:
+			i++
+		}
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isIdentEnd reports whether the digit at i continues an identifier, eg the 2 of out2.
+func isIdentEnd(query string, i int) bool {
+	return i > 0 && isIdentChar(query[i-1])
+}
+
+// isOperandStart reports whether a literal may start after the normalized text, so a following `-` is a sign.
+func isOperandStart(normalized string) bool {
+	normalized = strings.TrimRight(normalized, " ")
+	if normalized == "" {
+		return true
+	}
+	switch normalized[len(normalized)-1] {
+	case '(', ',', '[', '=', ':':
+		return true
+	}
+	return false
+}
+
+// skipString returns the index after the string literal starting at i, quotes are escaped by backslash.
+func skipString(query string, i int) int {
+	quote := query[i]
+	for i++; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			i++
+		case quote:
+			return i + 1
+		}
+	}
+	return i
+}
+
+// skipNumber returns the index after the number literal starting at i, including fraction, exponent and
+// type suffixes like 1L and 1.5d.
+func skipNumber(query string, i int) int {
+	for i < len(query) {
+		c := query[i]
+		switch {
+		case isIdentChar(c) || c == '.':
+			i++
+		case (c == '+' || c == '-') && (query[i-1] == 'e' || query[i-1] == 'E'):
+			i++
+		default:
+			return i
+		}
+	}
+	return i
+}
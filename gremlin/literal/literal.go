@@ -0,0 +1,169 @@
+// Copyright 2022 Beijing Volcanoengine Technology Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package literal renders Go values as Gremlin literals, which can be embedded into query text safely.
+package literal
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/volcengine/vegraph-go-sdk/gerrors"
+	"github.com/volcengine/vegraph-go-sdk/structure"
+)
+
+// Format renders v as Gremlin literal. v can be a bool, an integer, a float, a string, a type based on them,
+// or one of the structure elements Bool, Int32, Int64, Float32, Float64, String, *Property and *Vertex.
+// A *Vertex is rendered as vertex(id, type), a *Property as its value.
+func Format(v interface{}) (string, error) {
+	switch val := v.(type) {
+	case string:
+		if err := validateString(val); err != nil {
+			return "", err
+		}
+		return Quote(val), nil
+	case structure.String:
+		return Format(string(val))
+	case structure.Bool:
+		return Format(bool(val))
+	case structure.Int32:
+		return Format(int32(val))
+	case structure.Int64:
+		return Format(int64(val))
+	case structure.Float32:
+		return Format(float32(val))
+	case structure.Float64:
+		return Format(float64(val))
+	case *structure.Property:
+		if val == nil {
+			return "", invalid(v, "nil property")
+		}
+		return Format(val.Value)
+	case *structure.Vertex:
+		return formatVertex(val)
+	case structure.Element:
+		return "", invalid(v, "element type is not supported")
+	case nil:
+		return "", invalid(v, "nil value")
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if rv.Uint() > math.MaxInt64 {
+			return "", invalid(v, "out of int64 range")
+		}
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32:
+		return formatFloat(v, rv.Float(), 32)
+	case reflect.Float64:
+		return formatFloat(v, rv.Float(), 64)
+	case reflect.String:
+		return Format(rv.String())
+	default:
+		return "", invalid(v, "type is not supported")
+	}
+}
+
+// Validate checks whether v can be rendered by Format.
+func Validate(v interface{}) error {
+	_, err := Format(v)
+	return err
+}
+
+// Quote renders s as a single quoted Gremlin string literal, quotes, backslashes and control characters are escaped.
+// Unlike Format, it doesn't reject invalid UTF-8, which is kept as is.
+func Quote(s string) string {
+	var b strings.Builder
+	b.Grow(len(s) + 2)
+	b.WriteByte('\'')
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == '\'':
+			b.WriteString(`\'`)
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\b':
+			b.WriteString(`\b`)
+		case r == '\f':
+			b.WriteString(`\f`)
+		case r < ' ' || r == 0x7f:
+			b.WriteString(fmt.Sprintf(`\u%04x`, r))
+		default:
+			b.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
+
+func formatVertex(v *structure.Vertex) (string, error) {
+	if v == nil {
+		return "", invalid(v, "nil vertex")
+	}
+	switch v.VType {
+	case structure.IdTypeInt64Int32:
+		return fmt.Sprintf("vertex(%d, %d)", v.Id, v.Type), nil
+	case structure.IdTypeStringString:
+		id, err := Format(v.SId)
+		if err != nil {
+			return "", err
+		}
+		vtype, err := Format(v.SType)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("vertex(%s, %s)", id, vtype), nil
+	default:
+		return "", invalid(v, "unknown vertex id type")
+	}
+}
+
+func formatFloat(v interface{}, f float64, bitSize int) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", invalid(v, "not a finite number")
+	}
+	s := strconv.FormatFloat(f, 'f', -1, bitSize)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	return s, nil
+}
+
+func validateString(s string) error {
+	if !utf8.ValidString(s) {
+		return invalid(s, "invalid UTF-8")
+	}
+	return nil
+}
+
+func invalid(v interface{}, reason string) error {
+	return gerrors.New(gerrors.ErrorCode_INVALID_REQUEST, fmt.Errorf("cannot represent %T(%v) as gremlin literal: %s", v, v, reason))
+}
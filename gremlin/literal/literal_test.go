@@ -0,0 +1,57 @@
+package literal
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/volcengine/vegraph-go-sdk/structure"
+)
+
+type userID int64
+
+func TestFormat(t *testing.T) {
+	cases := []struct {
+		value    interface{}
+		expected string
+	}{
+		{"段正淳", `'段正淳'`},
+		{`it's`, `'it\'s'`},
+		{`a\'b`, `'a\\\'b'`},
+		{"line\nbreak\x00", `'line\nbreak\u0000'`},
+		{true, "true"},
+		{int32(-1), "-1"},
+		{uint64(math.MaxInt64), "9223372036854775807"},
+		{userID(7), "7"},
+		{float32(1.5), "1.5"},
+		{float64(3), "3.0"},
+		{structure.String("x'"), `'x\''`},
+		{structure.Int64(10), "10"},
+		{&structure.Property{Key: "name", Value: "v"}, `'v'`},
+		{&structure.Vertex{Id: 1, Type: 1001}, "vertex(1, 1001)"},
+		{&structure.Vertex{SId: "a'", SType: "user", VType: structure.IdTypeStringString}, `vertex('a\'', 'user')`},
+	}
+	for _, c := range cases {
+		lit, err := Format(c.value)
+		assert.NoError(t, err)
+		assert.Equal(t, c.expected, lit)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	invalids := []interface{}{
+		nil,
+		math.NaN(),
+		math.Inf(1),
+		uint64(math.MaxUint64),
+		"\xff",
+		[]byte("bytes"),
+		struct{}{},
+		structure.List{structure.Int64(1)},
+		&structure.Edge{},
+	}
+	for _, v := range invalids {
+		assert.Error(t, Validate(v), "%#v", v)
+	}
+	assert.NoError(t, Validate("ok"))
+}
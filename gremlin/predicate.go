@@ -0,0 +1,58 @@
+// Copyright 2022 Beijing Volcanoengine Technology Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gremlin
+
+// P is a predicate used by Has, eg Has("age", Gt(18)).
+type P struct {
+	name   string
+	values []interface{}
+}
+
+func Eq(value interface{}) *P {
+	return &P{name: "eq", values: []interface{}{value}}
+}
+
+func Neq(value interface{}) *P {
+	return &P{name: "neq", values: []interface{}{value}}
+}
+
+func Gt(value interface{}) *P {
+	return &P{name: "gt", values: []interface{}{value}}
+}
+
+func Gte(value interface{}) *P {
+	return &P{name: "gte", values: []interface{}{value}}
+}
+
+func Lt(value interface{}) *P {
+	return &P{name: "lt", values: []interface{}{value}}
+}
+
+func Lte(value interface{}) *P {
+	return &P{name: "lte", values: []interface{}{value}}
+}
+
+// Between matches values in range [low, high).
+func Between(low, high interface{}) *P {
+	return &P{name: "between", values: []interface{}{low, high}}
+}
+
+func Within(values ...interface{}) *P {
+	return &P{name: "within", values: values}
+}
+
+func Without(values ...interface{}) *P {
+	return &P{name: "without", values: values}
+}
@@ -0,0 +1,367 @@
+// Copyright 2022 Beijing Volcanoengine Technology Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gremlin builds Gremlin query text with a fluent API, eg:
+//
+//	query, err := gremlin.G().V(1, 1001).OutE("like").Has("weight", gremlin.Gt(10)).Limit(10).Build()
+//
+// Values are rendered as escaped literals by package literal, or as template parameters by Template.
+package gremlin
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/volcengine/vegraph-go-sdk/gerrors"
+	"github.com/volcengine/vegraph-go-sdk/gremlin/literal"
+)
+
+const (
+	vertexIdKey   = "id"
+	vertexTypeKey = "type"
+)
+
+type argKind int
+
+const (
+	// argKey is a step name, property key or label, always rendered as literal.
+	argKey argKind = iota
+	// argValue is a value, rendered as template parameter by Template.
+	argValue
+	// argPredicate is a predicate, whose values are rendered as argValue.
+	argPredicate
+	// argTraversal is an anonymous traversal created by Anon.
+	argTraversal
+)
+
+type arg struct {
+	kind  argKind
+	value interface{}
+}
+
+type step struct {
+	name string
+	args []arg
+}
+
+// Traversal is a Gremlin traversal under construction. Steps are appended in place and the traversal itself
+// is returned for chaining, so a Traversal should not be shared by goroutines. Errors of steps are deferred
+// to Build and Template.
+type Traversal struct {
+	steps     []step
+	err       error
+	anonymous bool
+}
+
+// G starts a traversal from the graph traversal source `g`.
+func G() *Traversal {
+	return &Traversal{}
+}
+
+// Anon starts an anonymous traversal, which is used as argument of steps like Where.
+func Anon() *Traversal {
+	return &Traversal{anonymous: true}
+}
+
+func (t *Traversal) addStep(name string, args ...arg) *Traversal {
+	t.steps = append(t.steps, step{name: name, args: args})
+	return t
+}
+
+func (t *Traversal) addError(err error) *Traversal {
+	if t.err == nil {
+		t.err = gerrors.New(gerrors.ErrorCode_INVALID_REQUEST, err)
+	}
+	return t
+}
+
+func keyArg(key string) arg {
+	return arg{kind: argKey, value: key}
+}
+
+func valueArgs(values ...interface{}) []arg {
+	args := make([]arg, 0, len(values))
+	for _, v := range values {
+		if p, ok := v.(*P); ok {
+			args = append(args, arg{kind: argPredicate, value: p})
+			continue
+		}
+		args = append(args, arg{kind: argValue, value: v})
+	}
+	return args
+}
+
+// V starts from all vertices, or the vertex of the given id and type, eg V(1, 1001) or V("sid", "stype").
+func (t *Traversal) V(idType ...interface{}) *Traversal {
+	switch len(idType) {
+	case 0:
+		return t.addStep("V")
+	case 2:
+		return t.addStep("V").Has(vertexIdKey, idType[0]).Has(vertexTypeKey, idType[1])
+	default:
+		return t.addError(fmt.Errorf("V takes no argument or both id and type, got %d arguments", len(idType)))
+	}
+}
+
+// AddV adds a vertex, its id and type should be set by Property.
+func (t *Traversal) AddV() *Traversal {
+	return t.addStep("addV")
+}
+
+// AddE adds an edge of the given type, the vertices should be set by From and To.
+func (t *Traversal) AddE(edgeType string) *Traversal {
+	return t.addStep("addE", keyArg(edgeType))
+}
+
+// From sets the out vertex of the edge added by AddE.
+func (t *Traversal) From(id, vtype interface{}) *Traversal {
+	return t.addStep("from", valueArgs(id, vtype)...)
+}
+
+// To sets the in vertex of the edge added by AddE.
+func (t *Traversal) To(id, vtype interface{}) *Traversal {
+	return t.addStep("to", valueArgs(id, vtype)...)
+}
+
+// Property sets a property of the current vertex or edge.
+func (t *Traversal) Property(key string, value interface{}) *Traversal {
+	return t.addStep("property", append([]arg{keyArg(key)}, valueArgs(value)...)...)
+}
+
+// Has filters by property key, and optionally by a value or a predicate, eg Has("age", 18) or Has("age", Gt(18)).
+func (t *Traversal) Has(key string, value ...interface{}) *Traversal {
+	if len(value) > 1 {
+		return t.addError(fmt.Errorf("has(%q) takes at most one value, got %d", key, len(value)))
+	}
+	return t.addStep("has", append([]arg{keyArg(key)}, valueArgs(value...)...)...)
+}
+
+// HasNot filters out elements having the property key.
+func (t *Traversal) HasNot(key string) *Traversal {
+	return t.addStep("hasNot", keyArg(key))
+}
+
+func keyArgs(keys []string) []arg {
+	args := make([]arg, 0, len(keys))
+	for _, k := range keys {
+		args = append(args, keyArg(k))
+	}
+	return args
+}
+
+// Out moves to the adjacent out vertices, by the given edge types.
+func (t *Traversal) Out(edgeTypes ...string) *Traversal {
+	return t.addStep("out", keyArgs(edgeTypes)...)
+}
+
+// In moves to the adjacent in vertices, by the given edge types.
+func (t *Traversal) In(edgeTypes ...string) *Traversal {
+	return t.addStep("in", keyArgs(edgeTypes)...)
+}
+
+// Both moves to both the adjacent in and out vertices, by the given edge types.
+func (t *Traversal) Both(edgeTypes ...string) *Traversal {
+	return t.addStep("both", keyArgs(edgeTypes)...)
+}
+
+// OutE moves to the out edges, by the given edge types.
+func (t *Traversal) OutE(edgeTypes ...string) *Traversal {
+	return t.addStep("outE", keyArgs(edgeTypes)...)
+}
+
+// InE moves to the in edges, by the given edge types.
+func (t *Traversal) InE(edgeTypes ...string) *Traversal {
+	return t.addStep("inE", keyArgs(edgeTypes)...)
+}
+
+// BothE moves to both the in and out edges, by the given edge types.
+func (t *Traversal) BothE(edgeTypes ...string) *Traversal {
+	return t.addStep("bothE", keyArgs(edgeTypes)...)
+}
+
+func (t *Traversal) OutV() *Traversal {
+	return t.addStep("outV")
+}
+
+func (t *Traversal) InV() *Traversal {
+	return t.addStep("inV")
+}
+
+func (t *Traversal) BothV() *Traversal {
+	return t.addStep("bothV")
+}
+
+func (t *Traversal) OtherV() *Traversal {
+	return t.addStep("otherV")
+}
+
+// Properties emits the properties of the given keys, or all properties.
+func (t *Traversal) Properties(keys ...string) *Traversal {
+	return t.addStep("properties", keyArgs(keys)...)
+}
+
+// Values emits the property values of the given keys, or all property values.
+func (t *Traversal) Values(keys ...string) *Traversal {
+	return t.addStep("values", keyArgs(keys)...)
+}
+
+// ValueMap emits the property map of the given keys, or of all properties.
+func (t *Traversal) ValueMap(keys ...string) *Traversal {
+	return t.addStep("valueMap", keyArgs(keys)...)
+}
+
+func (t *Traversal) Limit(n int64) *Traversal {
+	return t.addStep("limit", valueArgs(n)...)
+}
+
+func (t *Traversal) Range(low, high int64) *Traversal {
+	return t.addStep("range", valueArgs(low, high)...)
+}
+
+func (t *Traversal) Count() *Traversal {
+	return t.addStep("count")
+}
+
+func (t *Traversal) Dedup() *Traversal {
+	return t.addStep("dedup")
+}
+
+func (t *Traversal) Drop() *Traversal {
+	return t.addStep("drop")
+}
+
+// Where filters by an anonymous traversal, eg Where(Anon().InV().Has("id", 2)).
+func (t *Traversal) Where(anon *Traversal) *Traversal {
+	if anon == nil || !anon.anonymous {
+		return t.addError(fmt.Errorf("where takes an anonymous traversal created by Anon"))
+	}
+	if anon.err != nil {
+		return t.addError(anon.err)
+	}
+	return t.addStep("where", arg{kind: argTraversal, value: anon})
+}
+
+// Step appends a step not covered by the builder, args are rendered as values.
+func (t *Traversal) Step(name string, args ...interface{}) *Traversal {
+	return t.addStep(name, valueArgs(args...)...)
+}
+
+// Build renders the traversal as query text, with values embedded as literals.
+func (t *Traversal) Build() (string, error) {
+	query, _, err := t.render(false)
+	return query, err
+}
+
+// Template renders the traversal as a template for Client.SubmitTemplate, values are replaced by
+// placeholders p0, p1 ... in order, which are bare binding names as Client.SubmitTemplate expects,
+// and returned in params.
+func (t *Traversal) Template() (template string, params map[string]interface{}, err error) {
+	return t.render(true)
+}
+
+func (t *Traversal) String() string {
+	query, err := t.Build()
+	if err != nil {
+		return fmt.Sprintf("<invalid traversal: %v>", err)
+	}
+	return query
+}
+
+type renderer struct {
+	b        strings.Builder
+	template bool
+	params   map[string]interface{}
+}
+
+func (t *Traversal) render(template bool) (string, map[string]interface{}, error) {
+	if t.err != nil {
+		return "", nil, t.err
+	}
+	r := &renderer{template: template}
+	if template {
+		r.params = make(map[string]interface{})
+	}
+	if err := r.writeTraversal(t); err != nil {
+		return "", nil, err
+	}
+	return r.b.String(), r.params, nil
+}
+
+func (r *renderer) writeTraversal(t *Traversal) error {
+	if !t.anonymous {
+		r.b.WriteString("g")
+	}
+	for i, s := range t.steps {
+		if i > 0 || !t.anonymous {
+			r.b.WriteString(".")
+		}
+		r.b.WriteString(s.name)
+		r.b.WriteString("(")
+		for i, a := range s.args {
+			if i > 0 {
+				r.b.WriteString(", ")
+			}
+			if err := r.writeArg(a); err != nil {
+				return gerrors.New(gerrors.ErrorCode_INVALID_REQUEST, fmt.Errorf("step %s: %w", s.name, err))
+			}
+		}
+		r.b.WriteString(")")
+	}
+	return nil
+}
+
+func (r *renderer) writeArg(a arg) error {
+	switch a.kind {
+	case argKey:
+		r.b.WriteString(literal.Quote(a.value.(string)))
+	case argPredicate:
+		return r.writePredicate(a.value.(*P))
+	case argTraversal:
+		return r.writeTraversal(a.value.(*Traversal))
+	default:
+		return r.writeValue(a.value)
+	}
+	return nil
+}
+
+func (r *renderer) writePredicate(p *P) error {
+	r.b.WriteString(p.name)
+	r.b.WriteString("(")
+	for i, v := range p.values {
+		if i > 0 {
+			r.b.WriteString(", ")
+		}
+		if err := r.writeValue(v); err != nil {
+			return err
+		}
+	}
+	r.b.WriteString(")")
+	return nil
+}
+
+func (r *renderer) writeValue(v interface{}) error {
+	if r.template {
+		name := "p" + strconv.Itoa(len(r.params))
+		r.params[name] = v
+		r.b.WriteString(name)
+		return nil
+	}
+	lit, err := literal.Format(v)
+	if err != nil {
+		return err
+	}
+	r.b.WriteString(lit)
+	return nil
+}
@@ -0,0 +1,92 @@
+package gremlin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuild(t *testing.T) {
+	cases := []struct {
+		traversal *Traversal
+		expected  string
+	}{
+		{G().V(1, 1001).Properties(), `g.V().has('id', 1).has('type', 1001).properties()`},
+		{G().V(int64(1), int32(1002)).OutE("like").Has("k", "v").Limit(10), `g.V().has('id', 1).has('type', 1002).outE('like').has('k', 'v').limit(10)`},
+		{G().V("sid", "stype").Has("weight", Gt(1.5)).Count(), `g.V().has('id', 'sid').has('type', 'stype').has('weight', gt(1.5)).count()`},
+		{G().V(1, 1001).Has("age", Within(1, 2)).ValueMap("name", "age"), `g.V().has('id', 1).has('type', 1001).has('age', within(1, 2)).valueMap('name', 'age')`},
+		{
+			G().AddV().Property("id", 1).Property("type", 1001).Property("name", "段正淳").Property("power", 60),
+			`g.addV().property('id', 1).property('type', 1001).property('name', '段正淳').property('power', 60)`,
+		},
+		{
+			G().AddE("relatives").From(1, 1001).To(2, 1001).Property("relation", "父女"),
+			`g.addE('relatives').from(1, 1001).to(2, 1001).property('relation', '父女')`,
+		},
+		{G().V(1, 1001).Property("name", `it's a \ test`), `g.V().has('id', 1).has('type', 1001).property('name', 'it\'s a \\ test')`},
+		{G().V(1, 1001).Property("score", float64(2)), `g.V().has('id', 1).has('type', 1001).property('score', 2.0)`},
+		{
+			G().V(1, 1001).OutE("follow").Where(Anon().InV().Has("id", 2).Has("type", 1001)).Property("w", 1),
+			`g.V().has('id', 1).has('type', 1001).outE('follow').where(inV().has('id', 2).has('type', 1001)).property('w', 1)`,
+		},
+	}
+	for _, c := range cases {
+		query, err := c.traversal.Build()
+		assert.NoError(t, err)
+		assert.Equal(t, c.expected, query)
+	}
+}
+
+func TestBuildError(t *testing.T) {
+	_, err := G().V(1).OutE().Build()
+	assert.Error(t, err)
+
+	_, err = G().V(1, 1001).Property("data", []int{1}).Build()
+	assert.Error(t, err)
+
+	_, err = G().V().Where(G().V()).Build()
+	assert.Error(t, err)
+}
+
+func TestTemplate(t *testing.T) {
+	template, params, err := G().V(1, 1001).OutE("like").Has("weight", Between(1, 10)).Limit(10).Template()
+	assert.NoError(t, err)
+	assert.Equal(t, `g.V().has('id', p0).has('type', p1).outE('like').has('weight', between(p2, p3)).limit(p4)`, template)
+	assert.Equal(t, map[string]interface{}{"p0": 1, "p1": 1001, "p2": 1, "p3": 10, "p4": int64(10)}, params)
+}
+
+func TestFingerprint(t *testing.T) {
+	cases := map[string]string{
+		`g.V().has('id', 1).has('type', 1001).outE('like').limit(10)`:                 `g.V().has(?, ?).has(?, ?).outE(?).limit(?)`,
+		`g.V().has("id",-1).has("type",2L).has('name', 'it\'s').has('w', gt(1.5e-3))`: `g.V().has(?,?).has(?,?).has(?, ?).has(?, gt(?))`,
+		"g.V(vertex(1, 1001)).\n  out2()  .count()":                                   `g.V(vertex(?, ?)). out2() .count()`,
+		`g.V().has('id', 1).has('type', 1001).properties().range(0, 10 - 1)`:          `g.V().has(?, ?).has(?, ?).properties().range(?, ? - ?)`,
+	}
+	for query, expected := range cases {
+		assert.Equal(t, expected, Fingerprint(query), query)
+	}
+	assert.Equal(t, Fingerprint(`g.V().has('id', 1)`), Fingerprint(`g.V().has('id', 2)`))
+}
+
+func TestStartVertex(t *testing.T) {
+	cases := []struct {
+		traversal *Traversal
+		id, vtype interface{}
+	}{
+		{G().V(1, 1001).OutE("like").Limit(10), 1, 1001},
+		{G().V("s'id", "stype").Properties(), "s'id", "stype"},
+		{G().AddV().Property("id", -1).Property("type", 1001).Property("name", "n"), -1, 1001},
+		{G().AddE("like").From(1, 1001).To(2, 1001), 1, 1001},
+	}
+	for _, c := range cases {
+		id, vtype, ok := c.traversal.StartVertex()
+		assert.True(t, ok, c.traversal.String())
+		assert.Equal(t, c.id, id)
+		assert.Equal(t, c.vtype, vtype)
+	}
+	for _, traversal := range []*Traversal{G().V().Count(), G().V(1), G().V().Has("id", Gt(1)).Has("type", 1001), G().AddE("like").To(2, 1001),
+		Anon().V(1, 1001), G().V().Has("type", 1001).Has("id", 1)} {
+		_, _, ok := traversal.StartVertex()
+		assert.False(t, ok, traversal.String())
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2022 Beijing Volcanoengine Technology Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gremlin
+
+// StartVertex returns the id and type of the vertex the traversal starts from, for the traversals built as
+// G().V(id, type)..., G().AddV().Property("id", id).Property("type", type)... and G().AddE(edgeType).From(id, type)...
+// ok is false for other traversals.
+func (t *Traversal) StartVertex() (id, vtype interface{}, ok bool) {
+	if t.err != nil || t.anonymous || len(t.steps) < 2 {
+		return nil, nil, false
+	}
+	first := t.steps[0]
+	switch {
+	case first.name == "V" && len(first.args) == 0 && len(t.steps) >= 3:
+		id, ok = keyValueStep(t.steps[1], "has", vertexIdKey)
+		if ok {
+			vtype, ok = keyValueStep(t.steps[2], "has", vertexTypeKey)
+		}
+	case first.name == "addV" && len(t.steps) >= 3:
+		id, ok = keyValueStep(t.steps[1], "property", vertexIdKey)
+		if ok {
+			vtype, ok = keyValueStep(t.steps[2], "property", vertexTypeKey)
+		}
+	case first.name == "addE":
+		from := t.steps[1]
+		ok = from.name == "from" && len(from.args) == 2 && from.args[0].kind == argValue && from.args[1].kind == argValue
+		if ok {
+			id, vtype = from.args[0].value, from.args[1].value
+		}
+	}
+	if !ok {
+		return nil, nil, false
+	}
+	return id, vtype, true
+}
+
+// keyValueStep returns the value of step s in the shape name(key, value).
+func keyValueStep(s step, name, key string) (interface{}, bool) {
+	if s.name != name || len(s.args) != 2 || s.args[0].kind != argKey || s.args[0].value != key || s.args[1].kind != argValue {
+		return nil, false
+	}
+	return s.args[1].value, true
+}
@@ -0,0 +1,194 @@
+// Copyright 2022 Beijing Volcanoengine Technology Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package structure
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/volcengine/vegraph-go-sdk/gerrors"
+)
+
+// As converts elem to a native Go value of type T. Scalars are converted to bool, integer, float and string types
+// of enough range, List to slice, Map to map, and Property to its value. If T is an Element type, elem is returned
+// as is, and if T is a struct, elem is bound by BindTo. For example:
+//
+//	names, err := As[[]string](elem)
+//	counts, err := As[map[string]int64](elem)
+func As[T any](elem Element) (T, error) {
+	var ret T
+	rv := reflect.ValueOf(&ret).Elem()
+	if err := convertTo(elem, rv, ""); err != nil {
+		return ret, err
+	}
+	return ret, nil
+}
+
+func convertTo(elem Element, dest reflect.Value, path string) error {
+	if elem == nil {
+		return mismatch(elem, dest.Type(), path, "nil element")
+	}
+	if reflect.TypeOf(elem).AssignableTo(dest.Type()) {
+		dest.Set(reflect.ValueOf(elem))
+		return nil
+	}
+	switch e := elem.(type) {
+	case *Property:
+		return convertValue(e.Value, dest, path+"."+e.Key)
+	case *ListStruct:
+		elem = List(e.Elems)
+	case *MapStruct:
+		elem = Map(e.Elems)
+	}
+
+	switch dest.Kind() {
+	case reflect.Slice:
+		l, ok := elem.(List)
+		if !ok {
+			return mismatch(elem, dest.Type(), path, "")
+		}
+		s := reflect.MakeSlice(dest.Type(), len(l), len(l))
+		for i, le := range l {
+			if err := convertTo(le, s.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		dest.Set(s)
+		return nil
+	case reflect.Map:
+		m, ok := elem.(Map)
+		if !ok {
+			return mismatch(elem, dest.Type(), path, "")
+		}
+		mv := reflect.MakeMapWithSize(dest.Type(), len(m))
+		for k, v := range m {
+			kv, vv := reflect.New(dest.Type().Key()).Elem(), reflect.New(dest.Type().Elem()).Elem()
+			if err := convertTo(k, kv, fmt.Sprintf("%s{key %v}", path, k)); err != nil {
+				return err
+			}
+			if err := convertTo(v, vv, fmt.Sprintf("%s[%v]", path, k)); err != nil {
+				return err
+			}
+			mv.SetMapIndex(kv, vv)
+		}
+		dest.Set(mv)
+		return nil
+	case reflect.Struct:
+		switch elem.(type) {
+		case *Vertex, *Edge, List, Map:
+		default:
+			return mismatch(elem, dest.Type(), path, "")
+		}
+		if err := elem.BindTo(dest.Addr().Interface()); err != nil {
+			return fmt.Errorf("%s: %w", pathOrRoot(path), err)
+		}
+		return nil
+	case reflect.Ptr:
+		pv := reflect.New(dest.Type().Elem())
+		if err := convertTo(elem, pv.Elem(), path); err != nil {
+			return err
+		}
+		dest.Set(pv)
+		return nil
+	}
+
+	switch e := elem.(type) {
+	case Bool:
+		return convertValue(bool(e), dest, path)
+	case Int32:
+		return convertValue(int32(e), dest, path)
+	case Int64:
+		return convertValue(int64(e), dest, path)
+	case Float32:
+		return convertValue(float32(e), dest, path)
+	case Float64:
+		return convertValue(float64(e), dest, path)
+	case String:
+		return convertValue(string(e), dest, path)
+	}
+	return mismatch(elem, dest.Type(), path, "")
+}
+
+// convertValue converts a scalar value, as decoded in Property, to dest.
+func convertValue(v interface{}, dest reflect.Value, path string) error {
+	if v == nil {
+		return mismatch(v, dest.Type(), path, "nil value")
+	}
+	if dest.Kind() == reflect.Ptr {
+		pv := reflect.New(dest.Type().Elem())
+		if err := convertValue(v, pv.Elem(), path); err != nil {
+			return err
+		}
+		dest.Set(pv)
+		return nil
+	}
+	if dest.Kind() == reflect.Interface && reflect.TypeOf(v).Implements(dest.Type()) {
+		dest.Set(reflect.ValueOf(v))
+		return nil
+	}
+	sv := reflect.ValueOf(v)
+	switch dest.Kind() {
+	case reflect.Bool:
+		if sv.Kind() == reflect.Bool {
+			dest.SetBool(sv.Bool())
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if sv.Kind() >= reflect.Int && sv.Kind() <= reflect.Int64 {
+			if dest.OverflowInt(sv.Int()) {
+				return mismatch(v, dest.Type(), path, "overflow")
+			}
+			dest.SetInt(sv.Int())
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if sv.Kind() >= reflect.Int && sv.Kind() <= reflect.Int64 {
+			if sv.Int() < 0 || dest.OverflowUint(uint64(sv.Int())) {
+				return mismatch(v, dest.Type(), path, "overflow")
+			}
+			dest.SetUint(uint64(sv.Int()))
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if sv.Kind() == reflect.Float32 || sv.Kind() == reflect.Float64 {
+			if dest.Kind() == reflect.Float32 && math.Abs(sv.Float()) > math.MaxFloat32 && !math.IsInf(sv.Float(), 0) {
+				return mismatch(v, dest.Type(), path, "overflow")
+			}
+			dest.SetFloat(sv.Float())
+			return nil
+		}
+	case reflect.String:
+		if sv.Kind() == reflect.String {
+			dest.SetString(sv.String())
+			return nil
+		}
+	}
+	return mismatch(v, dest.Type(), path, "")
+}
+
+func mismatch(v interface{}, t reflect.Type, path, reason string) error {
+	if reason != "" {
+		reason = ", " + reason
+	}
+	return fmt.Errorf("%w, %s: cannot convert %T to %v%s", gerrors.ErrOrmTypeMismatch, pathOrRoot(path), v, t, reason)
+}
+
+func pathOrRoot(path string) string {
+	if path == "" {
+		return "result"
+	}
+	return "result" + path
+}
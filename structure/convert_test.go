@@ -0,0 +1,68 @@
+package structure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/volcengine/vegraph-go-sdk/gerrors"
+)
+
+func TestAs(t *testing.T) {
+	i, err := As[int](Int64(3))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, i)
+
+	s, err := As[string](String("name"))
+	assert.NoError(t, err)
+	assert.Equal(t, "name", s)
+
+	names, err := As[[]string](List{String("a"), String("b")})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, names)
+
+	counts, err := As[map[string]int64](Map{String("a"): Int64(1), String("b"): Int32(2)})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{"a": 1, "b": 2}, counts)
+
+	nested, err := As[[][]float64](&ListStruct{Elems: []Element{List{Float64(1.5)}, List{}}})
+	assert.NoError(t, err)
+	assert.Equal(t, [][]float64{{1.5}, {}}, nested)
+
+	values, err := As[[]interface{}](List{&Property{Key: "age", Value: int32(18)}, String("x")})
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{&Property{Key: "age", Value: int32(18)}, String("x")}, values)
+
+	age, err := As[*int32](&Property{Key: "age", Value: int32(18)})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(18), *age)
+
+	vertex, err := As[*Vertex](&Vertex{Id: 1, Type: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), vertex.Id)
+
+	type user struct {
+		Id   int64 `gremlin:"id"`
+		Type int32 `gremlin:"type"`
+	}
+	u, err := As[user](&Vertex{Id: 1, Type: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, user{Id: 1, Type: 2}, u)
+}
+
+func TestAsMismatch(t *testing.T) {
+	_, err := As[string](Int64(1))
+	assert.ErrorIs(t, err, gerrors.ErrOrmTypeMismatch)
+
+	_, err = As[int32](Int64(1 << 40))
+	assert.ErrorIs(t, err, gerrors.ErrOrmTypeMismatch)
+
+	_, err = As[[]int64](List{Int64(1), String("x")})
+	assert.ErrorIs(t, err, gerrors.ErrOrmTypeMismatch)
+	assert.Contains(t, err.Error(), "result[1]")
+
+	_, err = As[map[string]int](List{})
+	assert.ErrorIs(t, err, gerrors.ErrOrmTypeMismatch)
+
+	_, err = As[struct{}](Path{})
+	assert.ErrorIs(t, err, gerrors.ErrOrmTypeMismatch)
+}
@@ -0,0 +1,149 @@
+// Copyright 2022 Beijing Volcanoengine Technology Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package structure
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/volcengine/vegraph-go-sdk/gerrors"
+)
+
+// VertexFrom is the reverse of Vertex.BindTo, it builds a Vertex from a struct with gremlin tags.
+// The `id` and `type` fields must be int64 and int32, or both string for IdTypeStringString vertex.
+// Other tagged fields are mapped to properties, a nil pointer or nil interface field is treated as absent property.
+func VertexFrom(src interface{}) (*Vertex, error) {
+	sv, st, err := getSrcIndirectValueAndType(src)
+	if err != nil {
+		return nil, err
+	}
+	v := &Vertex{}
+	var hasId, hasType, strId, strType bool
+	for i := 0; i < st.NumField(); i++ {
+		field, val := st.Field(i), sv.Field(i)
+		mapTarget := field.Tag.Get(gremlinObjectMappingTagKey)
+		switch mapTarget {
+		case "", "-":
+			continue
+		case gremlinVertexIdTagValue:
+			switch field.Type.Kind() {
+			case reflect.Int64:
+				v.Id = val.Int()
+			case reflect.String:
+				v.SId, v.VType, strId = val.String(), IdTypeStringString, true
+			default:
+				return nil, fmt.Errorf("%w, cannot map field %s to Vertex id, because it's type is not int64 or string", gerrors.ErrOrmTypeMismatch, field.Name)
+			}
+			hasId = true
+		case gremlinVertexTypeTagValue:
+			switch field.Type.Kind() {
+			case reflect.Int32:
+				v.Type = int32(val.Int())
+			case reflect.String:
+				v.SType, strType = val.String(), true
+			default:
+				return nil, fmt.Errorf("%w, cannot map field %s to Vertex type, because it's type is not int32 or string", gerrors.ErrOrmTypeMismatch, field.Name)
+			}
+			hasType = true
+		default:
+			if ppt, ok := propertyFrom(mapTarget, val); ok {
+				v.Properties = append(v.Properties, ppt)
+			}
+		}
+	}
+	if !hasId || !hasType {
+		return nil, fmt.Errorf("%w, %T must have both id and type tagged fields", gerrors.ErrOrmTypeMismatch, src)
+	}
+	if strId != strType {
+		return nil, fmt.Errorf("%w, id and type of %T must be both int or both string", gerrors.ErrOrmTypeMismatch, src)
+	}
+	return v, nil
+}
+
+// EdgeFrom is the reverse of Edge.BindTo, it builds an Edge from a struct with gremlin tags.
+// The `outV` and `inV` fields are mapped by VertexFrom, the `type` field must be string,
+// other tagged fields are mapped to properties.
+func EdgeFrom(src interface{}) (*Edge, error) {
+	sv, st, err := getSrcIndirectValueAndType(src)
+	if err != nil {
+		return nil, err
+	}
+	e := &Edge{}
+	for i := 0; i < st.NumField(); i++ {
+		field, val := st.Field(i), sv.Field(i)
+		mapTarget := field.Tag.Get(gremlinObjectMappingTagKey)
+		switch mapTarget {
+		case "", "-":
+			continue
+		case gremlinEdgeOutVTagValue:
+			if e.OutV, err = VertexFrom(val.Interface()); err != nil {
+				return nil, fmt.Errorf("map outV of edge failed, %w", err)
+			}
+		case gremlinEdgeInVTagValue:
+			if e.InV, err = VertexFrom(val.Interface()); err != nil {
+				return nil, fmt.Errorf("map inV of edge failed, %w", err)
+			}
+		case gremlinEdgeTypeTagValue:
+			if field.Type.Kind() != reflect.String {
+				return nil, fmt.Errorf("%w, cannot map field %s to Edge type, because it's type is not string", gerrors.ErrOrmTypeMismatch, field.Name)
+			}
+			e.Type = val.String()
+		default:
+			if ppt, ok := propertyFrom(mapTarget, val); ok {
+				e.Properties = append(e.Properties, ppt)
+			}
+		}
+	}
+	if e.OutV == nil || e.InV == nil || e.Type == "" {
+		return nil, fmt.Errorf("%w, %T must have outV, inV and non-empty type tagged fields", gerrors.ErrOrmTypeMismatch, src)
+	}
+	return e, nil
+}
+
+// propertyFrom maps a field to property, a nil pointer or nil interface is absent, other values including
+// zero ones are present.
+func propertyFrom(key string, val reflect.Value) (*Property, bool) {
+	if val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return nil, false
+		}
+		val = val.Elem()
+	}
+	if val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return nil, false
+		}
+		val = val.Elem()
+	}
+	if !val.CanInterface() {
+		return nil, false
+	}
+	return &Property{Key: key, Value: val.Interface()}, true
+}
+
+// getSrcIndirectValueAndType returns the struct value and type of src, which can be a struct or a pointer to struct.
+func getSrcIndirectValueAndType(src interface{}) (sv reflect.Value, st reflect.Type, err error) {
+	sv = reflect.ValueOf(src)
+	for sv.Kind() == reflect.Ptr {
+		if sv.IsNil() {
+			return sv, st, fmt.Errorf("%w, orm object cannot be nil", gerrors.ErrOrmTypeMismatch)
+		}
+		sv = sv.Elem()
+	}
+	if sv.Kind() != reflect.Struct {
+		return sv, st, fmt.Errorf("%w, orm object cannot be %v, must be struct", gerrors.ErrOrmTypeMismatch, sv.Kind())
+	}
+	return sv, sv.Type(), nil
+}
@@ -0,0 +1,73 @@
+package structure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type ormUser struct {
+	Id     int64   `gremlin:"id"`
+	Type   int32   `gremlin:"type"`
+	Name   string  `gremlin:"name"`
+	Nick   *string `gremlin:"nick"`
+	Ignore string
+}
+
+type ormFollow struct {
+	From  ormUser  `gremlin:"outV"`
+	To    *ormUser `gremlin:"inV"`
+	Type  string   `gremlin:"type"`
+	Since int64    `gremlin:"since"`
+}
+
+func TestVertexFrom(t *testing.T) {
+	v, err := VertexFrom(&ormUser{Id: 1, Type: 1001, Name: "段誉", Ignore: "x"})
+	assert.NoError(t, err)
+	assert.True(t, v.Eq(&Vertex{Id: 1, Type: 1001, Properties: []*Property{{Key: "name", Value: "段誉"}}}, true))
+
+	var user ormUser
+	assert.NoError(t, v.BindTo(&user))
+	assert.NoError(t, List{v.Properties[0]}.BindTo(&user))
+	assert.Equal(t, ormUser{Id: 1, Type: 1001, Name: "段誉"}, user)
+
+	sv, err := VertexFrom(struct {
+		Id   string `gremlin:"id"`
+		Type string `gremlin:"type"`
+	}{"sid", "stype"})
+	assert.NoError(t, err)
+	assert.Equal(t, &Vertex{SId: "sid", SType: "stype", VType: IdTypeStringString}, sv)
+
+	zero := int64(0)
+	pv, err := VertexFrom(struct {
+		Id    int64       `gremlin:"id"`
+		Type  int32       `gremlin:"type"`
+		Power *int64      `gremlin:"power"`
+		Age   *int64      `gremlin:"age"`
+		Extra interface{} `gremlin:"extra"`
+	}{Id: 1, Type: 1001, Power: &zero})
+	assert.NoError(t, err)
+	assert.Equal(t, []*Property{{Key: "power", Value: int64(0)}}, pv.Properties)
+
+	_, err = VertexFrom(struct {
+		Id   string `gremlin:"id"`
+		Type int32  `gremlin:"type"`
+	}{"sid", 0})
+	assert.Error(t, err)
+	_, err = VertexFrom(struct {
+		Name string `gremlin:"name"`
+	}{})
+	assert.Error(t, err)
+}
+
+func TestEdgeFrom(t *testing.T) {
+	e, err := EdgeFrom(ormFollow{From: ormUser{Id: 1, Type: 1001}, To: &ormUser{Id: 2, Type: 1001}, Type: "follow", Since: 2022})
+	assert.NoError(t, err)
+	assert.Equal(t, "follow", e.Type)
+	assert.Equal(t, int64(1), e.OutV.Id)
+	assert.Equal(t, int64(2), e.InV.Id)
+	assert.Equal(t, []*Property{{Key: "since", Value: int64(2022)}}, e.Properties)
+
+	_, err = EdgeFrom(ormFollow{Type: "follow"})
+	assert.Error(t, err)
+}
@@ -60,7 +60,11 @@ const (
 )
 
 type Extra struct {
-	Cost int64
+	Cost  int64
+	TxnId string
+	TxnTs int64
+	// RespExtra is the BaseResp.Extra of the response, shared by all queries of a batch, eg IsMaster, idc
+	RespExtra map[string]string
 }
 
 type ElementType int64